@@ -0,0 +1,129 @@
+// Command serve runs the generator on a schedule and exposes the result
+// over HTTP for pull-based consumers.
+package main
+
+import (
+	"context"
+	"flag"
+	"log"
+	"os"
+	"os/signal"
+	"strings"
+	"syscall"
+	"time"
+
+	"github.com/ilyasaftr/disposable-email-domains/internal/generator"
+	"github.com/ilyasaftr/disposable-email-domains/internal/server"
+)
+
+func main() {
+	log.SetFlags(0)
+
+	addr := flag.String("addr", ":8080", "HTTP listen address")
+	interval := flag.Duration("interval", 6*time.Hour, "how often to regenerate the lists")
+	authToken := flag.String("auth-token", "", "bearer token required for POST /v1/refresh (empty disables it)")
+
+	textDenyPath := flag.String("text-deny", "sources/deny-text.txt", "path to text deny sources list")
+	jsonDenyPath := flag.String("json-deny", "sources/deny-json.txt", "path to JSON deny sources list")
+	textAllowPath := flag.String("text-allow", "sources/allow-text.txt", "path to text allow sources list")
+	jsonAllowPath := flag.String("json-allow", "sources/allow-json.txt", "path to JSON allow sources list")
+	secureLocalPath := flag.String("secure", "sources/secure.txt", "path to local secure domains list")
+
+	textDenyOut := flag.String("out-text-deny", "lists/deny.txt", "output path for text deny list")
+	jsonDenyOut := flag.String("out-json-deny", "lists/deny.json", "output path for JSON deny list")
+	textAllowOut := flag.String("out-text-allow", "lists/allow.txt", "output path for text allow list")
+	jsonAllowOut := flag.String("out-json-allow", "lists/allow.json", "output path for JSON allow list")
+	denyWildcardsOut := flag.String("out-deny-wildcards", "lists/deny-wildcards.txt", "output path for *.base.com wildcard deny rules")
+
+	concurrency := flag.Int("concurrency", 8, "max number of sources to fetch in parallel")
+	perHostRPS := flag.Float64("per-host-rps", 2, "max requests per second to a single host")
+	cacheDir := flag.String("cache-dir", ".cache/sources", "on-disk HTTP cache directory (empty disables caching)")
+	cacheTTL := flag.Duration("cache-ttl", time.Hour, "how long a cached source is served without revalidation")
+
+	validateDNS := flag.Bool("validate-dns", false, "prune deny domains that fail DNS/MX validation")
+	resolvers := flag.String("resolvers", "", "comma-separated list of host:port DNS resolvers (empty uses the system resolver)")
+	validateConcurrency := flag.Int("validate-concurrency", 20, "max number of domains to DNS-validate in parallel")
+	deadRunsThreshold := flag.Int("dead-runs-threshold", 3, "consecutive dead runs before a domain is reported as dead")
+	statePath := flag.String("state", ".cache/validator-state.json", "path to the DNS validation state file")
+	validateCacheTTL := flag.Duration("validate-cache-ttl", time.Hour, "how long a prior DNS/MX result is trusted before a domain is re-checked (0 disables caching); matters most here, since Run fires on every regeneration tick")
+	pruneDead := flag.Bool("prune-dead", false, "remove domains classified dead from the deny list output")
+	denyDeadOut := flag.String("out-deny-dead", "lists/deny-dead.txt", "output path for domains classified dead by DNS validation")
+	flag.Parse()
+
+	mustLines := func(path string) []string {
+		b, err := os.ReadFile(path)
+		if err != nil {
+			return nil
+		}
+		return splitLines(string(b))
+	}
+	var resolverList []string
+	if *resolvers != "" {
+		resolverList = strings.Split(*resolvers, ",")
+	}
+
+	cfg := server.Config{
+		Addr:      *addr,
+		Interval:  *interval,
+		AuthToken: *authToken,
+		Generator: generator.Config{
+			TextDeny:         mustLines(*textDenyPath),
+			JSONDeny:         mustLines(*jsonDenyPath),
+			TextAllow:        mustLines(*textAllowPath),
+			JSONAllow:        mustLines(*jsonAllowPath),
+			SecureLocal:      mustLines(*secureLocalPath),
+			TextDenyOut:      *textDenyOut,
+			JSONDenyOut:      *jsonDenyOut,
+			TextAllowOut:     *textAllowOut,
+			JSONAllowOut:     *jsonAllowOut,
+			DenyWildcardsOut: *denyWildcardsOut,
+			Concurrency:      *concurrency,
+			PerHostRPS:       *perHostRPS,
+			CacheDir:         *cacheDir,
+			CacheTTL:         *cacheTTL,
+
+			ValidateDNS:         *validateDNS,
+			Resolvers:           resolverList,
+			ValidateConcurrency: *validateConcurrency,
+			DeadRunsThreshold:   *deadRunsThreshold,
+			StatePath:           *statePath,
+			ValidateCacheTTL:    *validateCacheTTL,
+			PruneDeadDomains:    *pruneDead,
+			DenyDeadOut:         *denyDeadOut,
+		},
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), syscall.SIGINT, syscall.SIGTERM)
+	defer stop()
+
+	log.Printf("serving on %s, regenerating every %s", cfg.Addr, cfg.Interval)
+	if err := server.New(cfg).Run(ctx); err != nil {
+		log.Fatal(err)
+	}
+}
+
+func splitLines(s string) []string {
+	// Accept both \n and \r\n, keep empty lines (filtered later)
+	var out []string
+	start := 0
+	for i := 0; i < len(s); i++ {
+		if s[i] == '\n' {
+			line := s[start:i]
+			if len(line) > 0 && line[len(line)-1] == '\r' {
+				line = line[:len(line)-1]
+			}
+			out = append(out, line)
+			start = i + 1
+		}
+	}
+	if start <= len(s) {
+		line := s[start:]
+		if len(line) > 0 && line[len(line)-1] == '\r' {
+			line = line[:len(line)-1]
+		}
+		if line != "" {
+			out = append(out, line)
+		}
+	}
+	return out
+}
@@ -0,0 +1,322 @@
+package generator
+
+import (
+	"bytes"
+	"compress/gzip"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/klauspost/compress/zstd"
+)
+
+// SourceDecoder turns a fetched source's raw body into a list of candidate
+// domains. Decoders are registered by name in decoderRegistry and selected
+// per source line via an optional "name:opt=val,..." prefix.
+type SourceDecoder interface {
+	Decode(body []byte) ([]string, error)
+}
+
+type decoderFactory func(opts map[string]string) (SourceDecoder, error)
+
+var decoderRegistry = map[string]decoderFactory{
+	"text":       newTextDecoder,
+	"json-array": newJSONArrayDecoder,
+	"json-path":  newJSONPathDecoder,
+	"csv":        newCSVDecoder,
+	"yaml":       newYAMLDecoder,
+	"hosts":      newHostsDecoder,
+	"regex":      newRegexDecoder,
+}
+
+// parseSourceSpec splits a sources/*.txt line into its URL and an optional
+// leading "decoder:opt=val,opt2=val2" prefix, e.g.
+//
+//	csv:col=2 https://example.com/list.csv
+//	json-path:path=domains https://example.com/list.json
+//	https://example.com/list.txt
+//
+// defaultDecoder is used when the line has no prefix.
+func parseSourceSpec(line, defaultDecoder string) (rawURL, decoderName string, opts map[string]string, err error) {
+	fields := strings.Fields(line)
+	if len(fields) == 0 {
+		return "", "", nil, fmt.Errorf("empty source line")
+	}
+
+	if len(fields) >= 2 && !strings.Contains(fields[0], "://") {
+		decoderName, opts = parseDecoderPrefix(fields[0])
+		rawURL = strings.Join(fields[1:], " ")
+		return rawURL, decoderName, opts, nil
+	}
+	return fields[0], defaultDecoder, nil, nil
+}
+
+func parseDecoderPrefix(prefix string) (name string, opts map[string]string) {
+	name, rest, hasOpts := strings.Cut(prefix, ":")
+	if !hasOpts || rest == "" {
+		return name, nil
+	}
+	opts = make(map[string]string)
+	for _, pair := range strings.Split(rest, ",") {
+		k, v, _ := strings.Cut(pair, "=")
+		opts[k] = v
+	}
+	return name, opts
+}
+
+// newDecoder resolves a decoder by name via decoderRegistry.
+func newDecoder(name string, opts map[string]string) (SourceDecoder, error) {
+	factory, ok := decoderRegistry[name]
+	if !ok {
+		return nil, fmt.Errorf("unknown source decoder %q", name)
+	}
+	return factory(opts)
+}
+
+// zstdMagic is the 4-byte frame magic number every zstd-compressed payload
+// starts with (RFC 8478 §3.1.1).
+var zstdMagic = []byte{0x28, 0xB5, 0x2F, 0xFD}
+
+// maybeDecompress transparently decompresses body based on the source URL's
+// suffix, for sources that serve a compressed payload without a matching
+// Content-Encoding header. net/http's default transport already strips a
+// gzip Content-Encoding before the body reaches here, but it has no built-in
+// support for zstd, so a source that sends "Content-Encoding: zstd" is
+// handled separately by decompressContentEncoding in fetch.go, independent
+// of the URL's suffix. alreadyDecompressed is true when that Content-Encoding
+// pass already ran; it short-circuits this one so a .zst URL that also sends
+// "Content-Encoding: zstd" isn't decompressed twice (the second pass would
+// fail decoding already-plaintext bytes as zstd). The zstd case additionally
+// checks body's magic number rather than trusting alreadyDecompressed alone,
+// since an on-disk cache entry written before that flag existed has no way
+// to report it but may already hold decompressed bytes.
+func maybeDecompress(rawURL string, body []byte, alreadyDecompressed bool) ([]byte, error) {
+	if alreadyDecompressed {
+		return body, nil
+	}
+	switch {
+	case strings.HasSuffix(rawURL, ".gz"):
+		r, err := gzip.NewReader(bytes.NewReader(body))
+		if err != nil {
+			return nil, fmt.Errorf("gzip: %w", err)
+		}
+		defer r.Close()
+		return io.ReadAll(r)
+	case strings.HasSuffix(rawURL, ".zst"):
+		if !bytes.HasPrefix(body, zstdMagic) {
+			return body, nil
+		}
+		return decodeZstd(body)
+	default:
+		return body, nil
+	}
+}
+
+// decodeZstd decompresses a zstd-compressed body.
+func decodeZstd(body []byte) ([]byte, error) {
+	r, err := zstd.NewReader(nil)
+	if err != nil {
+		return nil, fmt.Errorf("zstd: %w", err)
+	}
+	defer r.Close()
+	return r.DecodeAll(body, nil)
+}
+
+// decompressContentEncoding decompresses body if encoding names a
+// compression net/http's transport doesn't already strip transparently
+// (currently just zstd; gzip is handled before the body ever reaches
+// fetchCached's caller). Unrecognized or empty encodings pass body through
+// unchanged.
+func decompressContentEncoding(encoding string, body []byte) ([]byte, error) {
+	if strings.EqualFold(encoding, "zstd") {
+		return decodeZstd(body)
+	}
+	return body, nil
+}
+
+// --- text ---
+
+type textDecoder struct{}
+
+func newTextDecoder(map[string]string) (SourceDecoder, error) { return textDecoder{}, nil }
+
+func (textDecoder) Decode(body []byte) ([]string, error) {
+	return strings.Split(string(body), "\n"), nil
+}
+
+// --- json-array: a top-level JSON array of strings ---
+
+type jsonArrayDecoder struct{}
+
+func newJSONArrayDecoder(map[string]string) (SourceDecoder, error) { return jsonArrayDecoder{}, nil }
+
+func (jsonArrayDecoder) Decode(body []byte) ([]string, error) {
+	var arr []string
+	if err := json.Unmarshal(body, &arr); err != nil {
+		return nil, fmt.Errorf("decode json: %w", err)
+	}
+	return arr, nil
+}
+
+// --- json-path: extract strings from arbitrary JSON via a dotted path ---
+
+type jsonPathDecoder struct {
+	path []string
+}
+
+func newJSONPathDecoder(opts map[string]string) (SourceDecoder, error) {
+	path := opts["path"]
+	if path == "" {
+		return nil, fmt.Errorf("json-path decoder requires a path= option")
+	}
+	return jsonPathDecoder{path: strings.Split(path, ".")}, nil
+}
+
+func (d jsonPathDecoder) Decode(body []byte) ([]string, error) {
+	var v interface{}
+	if err := json.Unmarshal(body, &v); err != nil {
+		return nil, fmt.Errorf("decode json: %w", err)
+	}
+	var out []string
+	walkJSONPath(v, d.path, &out)
+	return out, nil
+}
+
+// walkJSONPath descends v according to path, collecting every string value
+// reached. A slice encountered anywhere in v is transparently iterated
+// against the remaining path segments, so both {"domains":[...]} (path
+// "domains") and [{"domain":"x"}] (path "domain") work without a distinct
+// array syntax.
+func walkJSONPath(v interface{}, path []string, out *[]string) {
+	switch t := v.(type) {
+	case []interface{}:
+		for _, elem := range t {
+			walkJSONPath(elem, path, out)
+		}
+	case map[string]interface{}:
+		if len(path) == 0 {
+			return
+		}
+		walkJSONPath(t[path[0]], path[1:], out)
+	case string:
+		if len(path) == 0 {
+			*out = append(*out, t)
+		}
+	}
+}
+
+// --- csv ---
+
+type csvDecoder struct {
+	col int // zero-indexed
+}
+
+func newCSVDecoder(opts map[string]string) (SourceDecoder, error) {
+	col := 0
+	if raw, ok := opts["col"]; ok {
+		n, err := strconv.Atoi(raw)
+		if err != nil || n < 1 {
+			return nil, fmt.Errorf("csv decoder: invalid col=%q", raw)
+		}
+		col = n - 1
+	}
+	return csvDecoder{col: col}, nil
+}
+
+func (d csvDecoder) Decode(body []byte) ([]string, error) {
+	r := csv.NewReader(bytes.NewReader(body))
+	r.FieldsPerRecord = -1
+	var out []string
+	for {
+		record, err := r.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("csv: %w", err)
+		}
+		if d.col < len(record) {
+			out = append(out, record[d.col])
+		}
+	}
+	return out, nil
+}
+
+// --- yaml: a minimal flat-sequence reader ---
+//
+// The sources this decoder targets are always a flat list of domains, e.g.
+//
+//   - foo.com
+//   - bar.com
+//
+// so rather than vendor a full YAML parser we only support that shape.
+type yamlDecoder struct{}
+
+func newYAMLDecoder(map[string]string) (SourceDecoder, error) { return yamlDecoder{}, nil }
+
+func (yamlDecoder) Decode(body []byte) ([]string, error) {
+	var out []string
+	for _, line := range strings.Split(string(body), "\n") {
+		line = strings.TrimSpace(line)
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		if !strings.HasPrefix(line, "-") {
+			return nil, fmt.Errorf("yaml: not a flat sequence entry: %q", line)
+		}
+		line = strings.TrimSpace(strings.TrimPrefix(line, "-"))
+		line = strings.Trim(line, `"'`)
+		if line != "" {
+			out = append(out, line)
+		}
+	}
+	return out, nil
+}
+
+// --- hosts: /etc/hosts-style "0.0.0.0 baddomain.com" lines ---
+
+type hostsDecoder struct{}
+
+func newHostsDecoder(map[string]string) (SourceDecoder, error) { return hostsDecoder{}, nil }
+
+func (hostsDecoder) Decode(body []byte) ([]string, error) {
+	var out []string
+	for _, line := range strings.Split(string(body), "\n") {
+		line = strings.TrimSpace(line)
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		fields := strings.Fields(line)
+		if len(fields) < 2 {
+			continue
+		}
+		out = append(out, fields[1:]...)
+	}
+	return out, nil
+}
+
+// --- regex: extract domains matching a user-supplied pattern ---
+
+type regexDecoder struct {
+	re *regexp.Regexp
+}
+
+func newRegexDecoder(opts map[string]string) (SourceDecoder, error) {
+	pattern := opts["pattern"]
+	if pattern == "" {
+		return nil, fmt.Errorf("regex decoder requires a pattern= option")
+	}
+	re, err := regexp.Compile(pattern)
+	if err != nil {
+		return nil, fmt.Errorf("regex decoder: %w", err)
+	}
+	return regexDecoder{re: re}, nil
+}
+
+func (d regexDecoder) Decode(body []byte) ([]string, error) {
+	return d.re.FindAllString(string(body), -1), nil
+}
@@ -0,0 +1,186 @@
+package generator
+
+import (
+	"testing"
+
+	"github.com/klauspost/compress/zstd"
+)
+
+func zstdCompress(t *testing.T, body []byte) []byte {
+	t.Helper()
+	w, err := zstd.NewWriter(nil)
+	if err != nil {
+		t.Fatalf("zstd.NewWriter: %v", err)
+	}
+	defer w.Close()
+	return w.EncodeAll(body, nil)
+}
+
+func TestMaybeDecompressSkipsAlreadyDecompressedBody(t *testing.T) {
+	plain := []byte("foo.com\nbar.com\n")
+
+	t.Run("zst URL with an already-decompressed body passes through unchanged", func(t *testing.T) {
+		got, err := maybeDecompress("https://example.com/deny.txt.zst", plain, true)
+		if err != nil {
+			t.Fatalf("maybeDecompress: %v", err)
+		}
+		if string(got) != string(plain) {
+			t.Errorf("maybeDecompress = %q, want unchanged %q", got, plain)
+		}
+	})
+
+	t.Run("zst URL with a still-compressed body is decompressed", func(t *testing.T) {
+		compressed := zstdCompress(t, plain)
+		got, err := maybeDecompress("https://example.com/deny.txt.zst", compressed, false)
+		if err != nil {
+			t.Fatalf("maybeDecompress: %v", err)
+		}
+		if string(got) != string(plain) {
+			t.Errorf("maybeDecompress = %q, want %q", got, plain)
+		}
+	})
+
+	t.Run("zst URL with a plain body and a false flag is not mistaken for zstd", func(t *testing.T) {
+		// Mirrors a cache entry written before the Decompressed field
+		// existed: alreadyDecompressed defaults to false, but the stored
+		// body is already plaintext. The magic-number check, not the flag,
+		// is what has to catch this.
+		got, err := maybeDecompress("https://example.com/deny.txt.zst", plain, false)
+		if err != nil {
+			t.Fatalf("maybeDecompress: %v", err)
+		}
+		if string(got) != string(plain) {
+			t.Errorf("maybeDecompress = %q, want unchanged %q", got, plain)
+		}
+	})
+}
+
+func TestCSVDecoderColOption(t *testing.T) {
+	body := []byte("domain,note\nfoo.com,seen once\nbar.com,seen twice\n")
+
+	t.Run("default column is the first", func(t *testing.T) {
+		d, err := newCSVDecoder(nil)
+		if err != nil {
+			t.Fatalf("newCSVDecoder: %v", err)
+		}
+		got, err := d.Decode(body)
+		if err != nil {
+			t.Fatalf("Decode: %v", err)
+		}
+		want := []string{"domain", "foo.com", "bar.com"}
+		if !equalStrings(got, want) {
+			t.Errorf("Decode = %v, want %v", got, want)
+		}
+	})
+
+	t.Run("col=2 selects the second column", func(t *testing.T) {
+		d, err := newCSVDecoder(map[string]string{"col": "2"})
+		if err != nil {
+			t.Fatalf("newCSVDecoder: %v", err)
+		}
+		got, err := d.Decode(body)
+		if err != nil {
+			t.Fatalf("Decode: %v", err)
+		}
+		want := []string{"note", "seen once", "seen twice"}
+		if !equalStrings(got, want) {
+			t.Errorf("Decode = %v, want %v", got, want)
+		}
+	})
+
+	t.Run("rows shorter than col are skipped", func(t *testing.T) {
+		d, err := newCSVDecoder(map[string]string{"col": "2"})
+		if err != nil {
+			t.Fatalf("newCSVDecoder: %v", err)
+		}
+		got, err := d.Decode([]byte("foo.com\nbar.com,has-second-col\n"))
+		if err != nil {
+			t.Fatalf("Decode: %v", err)
+		}
+		want := []string{"has-second-col"}
+		if !equalStrings(got, want) {
+			t.Errorf("Decode = %v, want %v", got, want)
+		}
+	})
+
+	t.Run("col=0 is rejected", func(t *testing.T) {
+		if _, err := newCSVDecoder(map[string]string{"col": "0"}); err == nil {
+			t.Fatal("newCSVDecoder: want error for col=0")
+		}
+	})
+}
+
+func TestJSONPathDecoder(t *testing.T) {
+	t.Run("object-rooted", func(t *testing.T) {
+		d, err := newJSONPathDecoder(map[string]string{"path": "domains"})
+		if err != nil {
+			t.Fatalf("newJSONPathDecoder: %v", err)
+		}
+		got, err := d.Decode([]byte(`{"domains": ["foo.com", "bar.com"]}`))
+		if err != nil {
+			t.Fatalf("Decode: %v", err)
+		}
+		want := []string{"foo.com", "bar.com"}
+		if !equalStrings(got, want) {
+			t.Errorf("Decode = %v, want %v", got, want)
+		}
+	})
+
+	t.Run("array-rooted", func(t *testing.T) {
+		d, err := newJSONPathDecoder(map[string]string{"path": "domain"})
+		if err != nil {
+			t.Fatalf("newJSONPathDecoder: %v", err)
+		}
+		got, err := d.Decode([]byte(`[{"domain": "foo.com"}, {"domain": "bar.com"}]`))
+		if err != nil {
+			t.Fatalf("Decode: %v", err)
+		}
+		want := []string{"foo.com", "bar.com"}
+		if !equalStrings(got, want) {
+			t.Errorf("Decode = %v, want %v", got, want)
+		}
+	})
+
+	t.Run("missing path= option is rejected", func(t *testing.T) {
+		if _, err := newJSONPathDecoder(nil); err == nil {
+			t.Fatal("newJSONPathDecoder: want error for missing path=")
+		}
+	})
+}
+
+func TestYAMLDecoderRejectsNonSequenceLines(t *testing.T) {
+	d, err := newYAMLDecoder(nil)
+	if err != nil {
+		t.Fatalf("newYAMLDecoder: %v", err)
+	}
+
+	t.Run("flat sequence decodes", func(t *testing.T) {
+		got, err := d.Decode([]byte("# comment\n- foo.com\n- \"bar.com\"\n\n- baz.com\n"))
+		if err != nil {
+			t.Fatalf("Decode: %v", err)
+		}
+		want := []string{"foo.com", "bar.com", "baz.com"}
+		if !equalStrings(got, want) {
+			t.Errorf("Decode = %v, want %v", got, want)
+		}
+	})
+
+	t.Run("a mapping key is rejected, not silently swallowed", func(t *testing.T) {
+		_, err := d.Decode([]byte("domains:\n  - foo.com\n"))
+		if err == nil {
+			t.Fatal("Decode: want error for non-sequence line")
+		}
+	})
+}
+
+func equalStrings(got, want []string) bool {
+	if len(got) != len(want) {
+		return false
+	}
+	for i := range got {
+		if got[i] != want[i] {
+			return false
+		}
+	}
+	return true
+}
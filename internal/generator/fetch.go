@@ -0,0 +1,336 @@
+package generator
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+)
+
+func readAllLimited(resp *http.Response) ([]byte, error) {
+	return io.ReadAll(io.LimitReader(resp.Body, 10<<20))
+}
+
+// SourceStats summarizes how a batch of source fetches resolved, so CI can
+// detect sources that have silently started failing or gone stale.
+type SourceStats struct {
+	Hits        int // served entirely from a fresh on-disk cache entry
+	Misses      int // fetched from the network with a 200
+	NotModified int // fetched from the network with a 304, cache revalidated
+	Failed      int // exhausted retries without a usable response
+
+	// PerSource breaks the above totals down by source URL, so CI can tell
+	// exactly which sources are degraded rather than just a failure count.
+	PerSource map[string]SourceResult
+}
+
+// SourceResult is the outcome of fetching a single source URL.
+type SourceResult struct {
+	Status string // "hit", "miss", "not-modified", or "failed"
+	Error  string // non-empty when Status is "failed"
+}
+
+func (s fetchStatus) String() string {
+	switch s {
+	case statusHit:
+		return "hit"
+	case statusMiss:
+		return "miss"
+	case statusNotModified:
+		return "not-modified"
+	default:
+		return "failed"
+	}
+}
+
+func (s *SourceStats) add(o SourceStats) {
+	s.Hits += o.Hits
+	s.Misses += o.Misses
+	s.NotModified += o.NotModified
+	s.Failed += o.Failed
+	if len(o.PerSource) == 0 {
+		return
+	}
+	if s.PerSource == nil {
+		s.PerSource = make(map[string]SourceResult, len(o.PerSource))
+	}
+	for url, res := range o.PerSource {
+		s.PerSource[url] = res
+	}
+}
+
+// cacheEntry is the on-disk representation of a single cached response.
+type cacheEntry struct {
+	ETag         string    `json:"etag,omitempty"`
+	LastModified string    `json:"last_modified,omitempty"`
+	FetchedAt    time.Time `json:"fetched_at"`
+	Body         []byte    `json:"body"`
+	// Decompressed records whether Body was already decompressed based on
+	// the response's Content-Encoding header, so a cache hit doesn't feed
+	// an already-plaintext body back through maybeDecompress's URL-suffix
+	// based pass.
+	Decompressed bool `json:"decompressed,omitempty"`
+}
+
+// httpCache is a simple on-disk HTTP response cache keyed on URL, honoring
+// ETag/Last-Modified for conditional revalidation. A zero-value httpCache
+// (empty Dir) disables caching entirely.
+type httpCache struct {
+	Dir string
+	TTL time.Duration
+}
+
+func (c *httpCache) enabled() bool { return c != nil && c.Dir != "" }
+
+func (c *httpCache) path(rawURL string) string {
+	sum := sha256.Sum256([]byte(rawURL))
+	return filepath.Join(c.Dir, hex.EncodeToString(sum[:])+".json")
+}
+
+func (c *httpCache) load(rawURL string) (*cacheEntry, bool) {
+	if !c.enabled() {
+		return nil, false
+	}
+	b, err := os.ReadFile(c.path(rawURL))
+	if err != nil {
+		return nil, false
+	}
+	var e cacheEntry
+	if err := json.Unmarshal(b, &e); err != nil {
+		return nil, false
+	}
+	return &e, true
+}
+
+func (c *httpCache) fresh(e *cacheEntry) bool {
+	if c.TTL <= 0 {
+		return false
+	}
+	return time.Since(e.FetchedAt) < c.TTL
+}
+
+func (c *httpCache) store(rawURL string, e *cacheEntry) error {
+	if !c.enabled() {
+		return nil
+	}
+	if err := os.MkdirAll(c.Dir, 0o755); err != nil {
+		return err
+	}
+	b, err := json.Marshal(e)
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(c.path(rawURL), b, 0o644)
+}
+
+// hostLimiter throttles outgoing requests to at most one per `interval` per
+// host, so a slow or abuse-sensitive source doesn't get hammered just
+// because our overall worker concurrency is high.
+type hostLimiter struct {
+	interval time.Duration
+	mu       sync.Mutex
+	next     map[string]time.Time
+}
+
+func newHostLimiter(rps float64) *hostLimiter {
+	if rps <= 0 {
+		return nil
+	}
+	return &hostLimiter{
+		interval: time.Duration(float64(time.Second) / rps),
+		next:     make(map[string]time.Time),
+	}
+}
+
+func (l *hostLimiter) wait(ctx context.Context, host string) error {
+	if l == nil {
+		return nil
+	}
+	l.mu.Lock()
+	now := time.Now()
+	wait := l.next[host].Sub(now)
+	if wait < 0 {
+		wait = 0
+	}
+	l.next[host] = now.Add(wait + l.interval)
+	l.mu.Unlock()
+
+	if wait <= 0 {
+		return nil
+	}
+	t := time.NewTimer(wait)
+	defer t.Stop()
+	select {
+	case <-t.C:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// fetchStatus classifies how fetchCached resolved a single URL.
+type fetchStatus int
+
+const (
+	statusMiss fetchStatus = iota
+	statusHit
+	statusNotModified
+	statusFailed
+)
+
+// fetchCached fetches url, transparently using cache as a conditional-request
+// revalidation cache: a fresh cache entry (within TTL) is returned without
+// touching the network; a stale entry is revalidated with If-None-Match /
+// If-Modified-Since and refreshed in place on a 304.
+func fetchCached(ctx context.Context, client *http.Client, cache *httpCache, limiter *hostLimiter, rawURL string) ([]byte, fetchStatus, bool, error) {
+	entry, cached := cache.load(rawURL)
+	if cached && cache.fresh(entry) {
+		return entry.Body, statusHit, entry.Decompressed, nil
+	}
+
+	if u, err := url.Parse(rawURL); err == nil {
+		if err := limiter.wait(ctx, u.Host); err != nil {
+			return nil, statusFailed, false, err
+		}
+	}
+
+	var lastErr error
+	for attempt := 0; attempt < 3; attempt++ {
+		req, err := http.NewRequestWithContext(ctx, http.MethodGet, rawURL, nil)
+		if err != nil {
+			return nil, statusFailed, false, err
+		}
+		req.Header.Set("User-Agent", "disposable-email-domains/1.0 (+github.com/ilyasaftr/disposable-email-domains)")
+		if cached {
+			if entry.ETag != "" {
+				req.Header.Set("If-None-Match", entry.ETag)
+			}
+			if entry.LastModified != "" {
+				req.Header.Set("If-Modified-Since", entry.LastModified)
+			}
+		}
+
+		resp, err := client.Do(req)
+		if err != nil {
+			lastErr = err
+		} else {
+			body, status, decompressed, ok, rerr := readFetchResponse(resp, cached, entry)
+			if ok {
+				newEntry := &cacheEntry{
+					ETag:         resp.Header.Get("ETag"),
+					LastModified: resp.Header.Get("Last-Modified"),
+					FetchedAt:    time.Now(),
+					Body:         body,
+					Decompressed: decompressed,
+				}
+				if status == statusNotModified {
+					newEntry.Body = entry.Body
+					newEntry.Decompressed = entry.Decompressed
+					if newEntry.ETag == "" {
+						newEntry.ETag = entry.ETag
+					}
+					if newEntry.LastModified == "" {
+						newEntry.LastModified = entry.LastModified
+					}
+				}
+				if err := cache.store(rawURL, newEntry); err != nil {
+					lastErr = err
+				}
+				return newEntry.Body, status, newEntry.Decompressed, nil
+			}
+			lastErr = rerr
+		}
+
+		select {
+		case <-time.After(time.Duration(attempt+1) * 500 * time.Millisecond):
+		case <-ctx.Done():
+			return nil, statusFailed, false, ctx.Err()
+		}
+	}
+	return nil, statusFailed, false, lastErr
+}
+
+func readFetchResponse(resp *http.Response, cached bool, entry *cacheEntry) (body []byte, status fetchStatus, decompressed bool, ok bool, err error) {
+	defer resp.Body.Close()
+	if resp.StatusCode == http.StatusNotModified && cached {
+		return entry.Body, statusNotModified, entry.Decompressed, true, nil
+	}
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return nil, statusFailed, false, false, fmt.Errorf("http %d", resp.StatusCode)
+	}
+	b, err := readAllLimited(resp)
+	if err != nil {
+		return nil, statusFailed, false, false, err
+	}
+	encoding := resp.Header.Get("Content-Encoding")
+	b, err = decompressContentEncoding(encoding, b)
+	if err != nil {
+		return nil, statusFailed, false, false, err
+	}
+	return b, statusMiss, strings.EqualFold(encoding, "zstd"), true, nil
+}
+
+// fetchPool runs fetch jobs with bounded concurrency, collecting per-URL
+// results in URL order regardless of completion order.
+type fetchPool struct {
+	client  *http.Client
+	cache   *httpCache
+	limiter *hostLimiter
+	sem     chan struct{}
+}
+
+func newFetchPool(client *http.Client, cache *httpCache, limiter *hostLimiter, concurrency int) *fetchPool {
+	if concurrency <= 0 {
+		concurrency = 4
+	}
+	return &fetchPool{client: client, cache: cache, limiter: limiter, sem: make(chan struct{}, concurrency)}
+}
+
+type fetchJob struct {
+	url string
+}
+
+type fetchResult struct {
+	url    string
+	body   []byte
+	status fetchStatus
+	// decompressed is true when body was already decompressed based on the
+	// response's Content-Encoding header, so the caller's URL-suffix based
+	// maybeDecompress pass can skip it - otherwise a .zst URL that also
+	// sends "Content-Encoding: zstd" gets decompressed twice, and the
+	// second pass fails decoding already-plaintext bytes as zstd.
+	decompressed bool
+	err          error
+}
+
+func (p *fetchPool) fetchAll(ctx context.Context, urls []string) []fetchResult {
+	results := make([]fetchResult, len(urls))
+	var wg sync.WaitGroup
+	for i, u := range urls {
+		wg.Add(1)
+		go func(i int, u string) {
+			defer wg.Done()
+			select {
+			case p.sem <- struct{}{}:
+			case <-ctx.Done():
+				results[i] = fetchResult{url: u, err: ctx.Err(), status: statusFailed}
+				return
+			}
+			defer func() { <-p.sem }()
+
+			body, status, decompressed, err := fetchCached(ctx, p.client, p.cache, p.limiter, u)
+			results[i] = fetchResult{url: u, body: body, status: status, decompressed: decompressed, err: err}
+		}(i, u)
+	}
+	wg.Wait()
+	return results
+}
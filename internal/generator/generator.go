@@ -6,7 +6,6 @@ import (
 	"encoding/json"
 	"errors"
 	"fmt"
-	"io"
 	"net/http"
 	"os"
 	"path/filepath"
@@ -15,6 +14,9 @@ import (
 	"time"
 
 	"golang.org/x/net/publicsuffix"
+
+	"github.com/ilyasaftr/disposable-email-domains/internal/validator"
+	"github.com/ilyasaftr/disposable-email-domains/pkg/checker"
 )
 
 type Config struct {
@@ -28,13 +30,84 @@ type Config struct {
 	JSONDenyOut  string
 	TextAllowOut string
 	JSONAllowOut string
+
+	// Concurrency bounds how many sources are fetched in parallel. Defaults
+	// to 4 when <= 0.
+	Concurrency int
+	// PerHostRPS caps outgoing requests per second to a given host. 0
+	// disables rate limiting.
+	PerHostRPS float64
+	// CacheDir, when set, enables an on-disk HTTP response cache keyed on
+	// URL that revalidates via ETag/Last-Modified. Empty disables caching.
+	CacheDir string
+	// CacheTTL is how long a cache entry is served without revalidation.
+	CacheTTL time.Duration
+
+	// ValidateDNS enables a DNS/MX liveness pass over the final deny list.
+	ValidateDNS bool
+	// Resolvers is a list of "host:port" DNS resolvers to validate against.
+	// Empty uses the system resolver.
+	Resolvers []string
+	// ValidateConcurrency bounds how many domains are checked in parallel.
+	// Defaults to 20 when <= 0.
+	ValidateConcurrency int
+	// DeadRunsThreshold is how many consecutive runs a domain must resolve
+	// as dead before it's reported in DenyDeadOut.
+	DeadRunsThreshold int
+	// StatePath persists the consecutive-dead-run counters between runs.
+	// Empty disables persistence.
+	StatePath string
+	// ValidateCacheTTL is how long a prior DNS/MX result is trusted before a
+	// domain is re-checked. 0 disables result caching, so every run
+	// re-validates every domain. Matters most for the serve daemon, where
+	// Run executes on every regeneration tick.
+	ValidateCacheTTL time.Duration
+	// PruneDeadDomains removes domains classified dead from the deny list
+	// output in addition to reporting them in DenyDeadOut.
+	PruneDeadDomains bool
+	// DenyDeadOut is the output path for domains classified dead by DNS/MX
+	// validation.
+	DenyDeadOut string
+
+	// DenyWildcardsOut is the output path for "*.base.com" wildcard rules
+	// found among the deny sources. Empty skips writing it.
+	DenyWildcardsOut string
+	// DenyAutomatonOut is the output path for a gob-encoded pkg/checker
+	// Matcher built from the final deny rules (exact and wildcard). Empty
+	// skips writing it.
+	DenyAutomatonOut string
+
+	// ManifestOut is the output path for a manifest recording sha256
+	// hashes, entry counts, and consulted source URLs for this run. Empty
+	// skips writing it.
+	ManifestOut string
+	// ChangelogDir, when set, writes a CHANGELOG-<date>.md diffing this
+	// run's deny list against the previously committed one.
+	ChangelogDir string
+	// DenyMinOut is the output path for the eTLD+1-collapsed deny list.
+	// Empty skips writing it.
+	DenyMinOut string
+	// SignKeyEnv/SignKeyPath locate a hex-encoded ed25519 private key used
+	// to sign ManifestOut. SignKeyEnv (an environment variable name) is
+	// tried first, then SignKeyPath (a file). Both empty skips signing.
+	SignKeyEnv  string
+	SignKeyPath string
 }
 
 // Run downloads sources, merges, cleans, and writes output lists.
-// It returns final deny/allow counts and a combined error (if any fetches failed).
-func Run(ctx context.Context, cfg Config) (denyCount, allowCount int, err error) {
-	deny, derr := obtainAllDomains(ctx, cfg.TextDeny, cfg.JSONDeny)
-	allow, aerr := obtainAllDomains(ctx, cfg.TextAllow, cfg.JSONAllow)
+// It returns final deny/allow counts, per-source fetch stats, and a
+// combined error (if any fetches failed).
+func Run(ctx context.Context, cfg Config) (denyCount, allowCount int, stats SourceStats, err error) {
+	client := &http.Client{Timeout: 30 * time.Second}
+	cache := &httpCache{Dir: cfg.CacheDir, TTL: cfg.CacheTTL}
+	limiter := newHostLimiter(cfg.PerHostRPS)
+	pool := newFetchPool(client, cache, limiter, cfg.Concurrency)
+
+	deny, denyConsulted, denyStats, derr := obtainAllDomains(ctx, pool, cfg.TextDeny, cfg.JSONDeny)
+	allow, allowConsulted, allowStats, aerr := obtainAllDomains(ctx, pool, cfg.TextAllow, cfg.JSONAllow)
+	stats.add(denyStats)
+	stats.add(allowStats)
+	consultedSources := uniqueSorted(append(denyConsulted, allowConsulted...))
 
 	if derr != nil || aerr != nil {
 		// combine partial errors but continue
@@ -56,95 +129,143 @@ func Run(ctx context.Context, cfg Config) (denyCount, allowCount int, err error)
 	allow = cleanDomains(allow)
 	secure = cleanDomains(secure)
 
+	deny, denyWildcards := splitWildcards(deny)
+	allow, _ = splitWildcards(allow)
+	secure, _ = splitWildcards(secure)
+
 	deny = removeSecureDomainsByETLD1(deny, secure)
 	deny = difference(uniqueSorted(deny), uniqueSorted(allow))
 	allow = uniqueSorted(append(allow, secure...))
 
-	if werr := writeOutputs(cfg, deny, allow); werr != nil {
-		if err != nil {
-			return 0, 0, fmt.Errorf("%v; %w", err, werr)
+	var deadDomains []string
+	if cfg.ValidateDNS {
+		aliveDomains, dead, _, verr := validator.Run(ctx, deny, validator.Config{
+			Resolvers:         cfg.Resolvers,
+			Concurrency:       cfg.ValidateConcurrency,
+			DeadRunsThreshold: cfg.DeadRunsThreshold,
+			StatePath:         cfg.StatePath,
+			CacheTTL:          cfg.ValidateCacheTTL,
+		})
+		if verr != nil {
+			if err != nil {
+				err = fmt.Errorf("%v; validate: %w", err, verr)
+			} else {
+				err = fmt.Errorf("validate: %w", verr)
+			}
+		} else {
+			deadDomains = dead
+			if cfg.PruneDeadDomains {
+				deny = aliveDomains
+			}
+		}
+	}
+	if cfg.ValidateDNS && cfg.DenyDeadOut != "" {
+		if werr := writeText(cfg.DenyDeadOut, deadDomains); werr != nil {
+			return 0, 0, stats, combineErr(err, werr)
 		}
-		return 0, 0, werr
 	}
-	return len(deny), len(allow), err
+
+	previousDeny, _ := readLines(cfg.TextDenyOut)
+
+	if werr := writeOutputs(cfg, deny, allow); werr != nil {
+		return 0, 0, stats, combineErr(err, werr)
+	}
+	if werr := writeWildcardOutputs(cfg, deny, denyWildcards); werr != nil {
+		return 0, 0, stats, combineErr(err, werr)
+	}
+	if werr := writeReleaseArtifacts(cfg, deny, allow, previousDeny, consultedSources, denyWildcards); werr != nil {
+		return 0, 0, stats, combineErr(err, werr)
+	}
+	return len(deny), len(allow), stats, err
 }
 
-func obtainAllDomains(ctx context.Context, textURLs, jsonURLs []string) ([]string, error) {
-	client := &http.Client{Timeout: 30 * time.Second}
+// combineErr joins a prior soft error (e.g. partial fetch failures) with a
+// new hard error from a write step, preferring to surface both.
+func combineErr(prev, next error) error {
+	if prev == nil {
+		return next
+	}
+	return fmt.Errorf("%v; %w", prev, next)
+}
+
+func obtainAllDomains(ctx context.Context, pool *fetchPool, textSources, jsonSources []string) ([]string, []string, SourceStats, error) {
 	var out []string
+	var consulted []string
 	var errs []string
+	var stats SourceStats
 
-	for _, u := range textURLs {
-		if strings.HasPrefix(u, "#") || strings.TrimSpace(u) == "" {
-			continue
-		}
-		lines, err := fetchTextLines(ctx, client, u)
-		if err != nil {
-			errs = append(errs, fmt.Sprintf("%s: %v", u, err))
-			continue
-		}
-		out = append(out, lines...)
+	type job struct {
+		url     string
+		decoder SourceDecoder
 	}
-	for _, u := range jsonURLs {
-		if strings.HasPrefix(u, "#") || strings.TrimSpace(u) == "" {
-			continue
+	var jobs []job
+	addJobs := func(lines []string, defaultDecoder string) {
+		for _, line := range lines {
+			if strings.HasPrefix(line, "#") || strings.TrimSpace(line) == "" {
+				continue
+			}
+			u, decoderName, opts, err := parseSourceSpec(line, defaultDecoder)
+			if err != nil {
+				errs = append(errs, fmt.Sprintf("%s: %v", line, err))
+				continue
+			}
+			dec, err := newDecoder(decoderName, opts)
+			if err != nil {
+				errs = append(errs, fmt.Sprintf("%s: %v", u, err))
+				continue
+			}
+			jobs = append(jobs, job{url: u, decoder: dec})
 		}
-		arr, err := fetchJSONStrings(ctx, client, u)
-		if err != nil {
-			errs = append(errs, fmt.Sprintf("%s: %v", u, err))
-			continue
-		}
-		out = append(out, arr...)
-	}
-	if len(errs) > 0 {
-		return out, errors.New(strings.Join(errs, "; "))
 	}
-	return out, nil
-}
+	addJobs(textSources, "text")
+	addJobs(jsonSources, "json-array")
 
-func fetchTextLines(ctx context.Context, client *http.Client, url string) ([]string, error) {
-	b, err := fetch(ctx, client, url)
-	if err != nil {
-		return nil, err
+	urls := make([]string, len(jobs))
+	for i, j := range jobs {
+		urls[i] = j.url
 	}
-	return strings.Split(string(b), "\n"), nil
-}
+	results := pool.fetchAll(ctx, urls)
 
-func fetchJSONStrings(ctx context.Context, client *http.Client, url string) ([]string, error) {
-	b, err := fetch(ctx, client, url)
-	if err != nil {
-		return nil, err
+	if stats.PerSource == nil {
+		stats.PerSource = make(map[string]SourceResult, len(results))
 	}
-	var arr []string
-	if err := json.Unmarshal(b, &arr); err != nil {
-		return nil, fmt.Errorf("decode json: %w", err)
+	recordFailure := func(url string, cause error) {
+		stats.Failed++
+		stats.PerSource[url] = SourceResult{Status: statusFailed.String(), Error: cause.Error()}
+		errs = append(errs, fmt.Sprintf("%s: %v", url, cause))
 	}
-	return arr, nil
-}
 
-func fetch(ctx context.Context, client *http.Client, url string) ([]byte, error) {
-	var lastErr error
-	for i := 0; i < 3; i++ {
-		req, _ := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
-        req.Header.Set("User-Agent", "disposable-email-domains/1.0 (+github.com/ilyasaftr/disposable-email-domains)")
-		resp, err := client.Do(req)
-		if err == nil && resp != nil && resp.StatusCode >= 200 && resp.StatusCode < 300 {
-			defer resp.Body.Close()
-			return io.ReadAll(io.LimitReader(resp.Body, 10<<20))
+	for i, res := range results {
+		switch res.status {
+		case statusHit:
+			stats.Hits++
+		case statusMiss:
+			stats.Misses++
+		case statusNotModified:
+			stats.NotModified++
 		}
-		if resp != nil {
-			lastErr = fmt.Errorf("http %d", resp.StatusCode)
-			resp.Body.Close()
-		} else {
-			lastErr = err
+		if res.err != nil {
+			recordFailure(res.url, res.err)
+			continue
+		}
+		body, err := maybeDecompress(res.url, res.body, res.decompressed)
+		if err != nil {
+			recordFailure(res.url, err)
+			continue
 		}
-		select {
-		case <-time.After(time.Duration(i+1) * 500 * time.Millisecond):
-		case <-ctx.Done():
-			return nil, ctx.Err()
+		lines, err := jobs[i].decoder.Decode(body)
+		if err != nil {
+			recordFailure(res.url, err)
+			continue
 		}
+		stats.PerSource[res.url] = SourceResult{Status: res.status.String()}
+		out = append(out, lines...)
+		consulted = append(consulted, res.url)
+	}
+	if len(errs) > 0 {
+		return out, consulted, stats, errors.New(strings.Join(errs, "; "))
 	}
-	return nil, lastErr
+	return out, consulted, stats, nil
 }
 
 func normalizeAndFilter(in []string) []string {
@@ -162,13 +283,49 @@ func normalizeAndFilter(in []string) []string {
 func cleanDomains(in []string) []string {
 	out := make([]string, 0, len(in))
 	for _, s := range in {
-		s = strings.TrimPrefix(s, "*.")
+		if strings.HasPrefix(s, "*.") {
+			out = append(out, s)
+			continue
+		}
 		s = strings.TrimPrefix(s, ".")
 		out = append(out, s)
 	}
 	return out
 }
 
+// splitWildcards separates "*.base.com" wildcard rules from plain domains,
+// returning the bare base domain in place of each wildcard rule (so the
+// result can flow through the same dedup/compare pipeline as exact
+// domains) along with a set recording which base domains came from a
+// wildcard rule.
+func splitWildcards(in []string) (bases []string, wildcard map[string]bool) {
+	wildcard = make(map[string]bool)
+	bases = make([]string, len(in))
+	for i, s := range in {
+		if base, ok := strings.CutPrefix(s, "*."); ok {
+			wildcard[base] = true
+			bases[i] = base
+		} else {
+			bases[i] = s
+		}
+	}
+	return bases, wildcard
+}
+
+// partitionWildcardRules splits deny into the exact-match rules and the
+// "*.base.com" wildcard rules implied by wildcard (as produced by
+// splitWildcards), in the deny.ac.bin/deny-wildcards.txt rule format.
+func partitionWildcardRules(deny []string, wildcard map[string]bool) (exactRules, wildcardRules []string) {
+	for _, d := range deny {
+		if wildcard[d] {
+			wildcardRules = append(wildcardRules, "*."+d)
+		} else {
+			exactRules = append(exactRules, d)
+		}
+	}
+	return exactRules, wildcardRules
+}
+
 func removeSecureDomainsByETLD1(deny, secure []string) []string {
 	sec := make(map[string]struct{}, len(secure))
 	for _, s := range secure {
@@ -245,6 +402,38 @@ func writeOutputs(cfg Config, deny, allow []string) error {
 	return nil
 }
 
+// writeWildcardOutputs writes the wildcard rule list and a gob-encoded
+// pkg/checker automaton built from the final deny rules, so downstream Go
+// consumers can load exact+wildcard matching without re-parsing text.
+func writeWildcardOutputs(cfg Config, deny []string, wildcard map[string]bool) error {
+	exactRules, wildcardRules := partitionWildcardRules(deny, wildcard)
+
+	if cfg.DenyWildcardsOut != "" {
+		if err := writeText(cfg.DenyWildcardsOut, wildcardRules); err != nil {
+			return err
+		}
+	}
+
+	if cfg.DenyAutomatonOut != "" {
+		m, err := checker.New(append(append([]string{}, exactRules...), wildcardRules...))
+		if err != nil {
+			return fmt.Errorf("build checker automaton: %w", err)
+		}
+		if err := ensureDir(filepath.Dir(cfg.DenyAutomatonOut)); err != nil {
+			return err
+		}
+		f, err := os.Create(cfg.DenyAutomatonOut)
+		if err != nil {
+			return err
+		}
+		defer f.Close()
+		if err := m.WriteGob(f); err != nil {
+			return fmt.Errorf("write checker automaton: %w", err)
+		}
+	}
+	return nil
+}
+
 func writeText(path string, lines []string) error {
 	if err := ensureDir(filepath.Dir(path)); err != nil {
 		return err
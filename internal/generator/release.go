@@ -0,0 +1,249 @@
+package generator
+
+import (
+	"bytes"
+	"crypto/ed25519"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/ilyasaftr/disposable-email-domains/pkg/checker"
+)
+
+// manifest records the artifacts produced by a run, so CI and downstream
+// consumers can verify integrity and see which sources actually fed into
+// it without re-running the generator.
+type manifest struct {
+	GeneratedAt      time.Time      `json:"generated_at"`
+	Files            []manifestFile `json:"files"`
+	SourcesConsulted []string       `json:"sources_consulted"`
+}
+
+type manifestFile struct {
+	Path    string `json:"path"`
+	SHA256  string `json:"sha256"`
+	Entries int    `json:"entries"`
+}
+
+// writeReleaseArtifacts writes the manifest (and optional signature), the
+// eTLD+1-collapsed deny list, and a changelog diffing deny against
+// previousDeny (the deny list as it stood before this run, or nil if there
+// wasn't one yet). denyWildcards identifies which deny entries are wildcard
+// bases, as produced by splitWildcards, so the manifest can cover the
+// wildcard list and automaton alongside the plain text/JSON outputs.
+func writeReleaseArtifacts(cfg Config, deny, allow, previousDeny, sourcesConsulted []string, denyWildcards map[string]bool) error {
+	denyMin := collapseToETLD1(deny)
+	if cfg.DenyMinOut != "" {
+		if err := writeText(cfg.DenyMinOut, denyMin); err != nil {
+			return err
+		}
+	}
+
+	if cfg.ChangelogDir != "" {
+		path := fmt.Sprintf("%s/CHANGELOG-%s.md", strings.TrimSuffix(cfg.ChangelogDir, "/"), time.Now().Format("2006-01-02"))
+		if err := ensureDir(filepath.Dir(path)); err != nil {
+			return err
+		}
+		if err := os.WriteFile(path, []byte(changelogMarkdown(previousDeny, deny)), 0o644); err != nil {
+			return err
+		}
+	}
+
+	if cfg.ManifestOut != "" {
+		exactRules, wildcardRules := partitionWildcardRules(deny, denyWildcards)
+
+		m := manifest{
+			GeneratedAt:      time.Now(),
+			SourcesConsulted: sourcesConsulted,
+		}
+		for _, f := range []struct {
+			path    string
+			content []byte
+			entries int
+		}{
+			{cfg.TextDenyOut, []byte(strings.Join(deny, "\n")), len(deny)},
+			{cfg.TextAllowOut, []byte(strings.Join(allow, "\n")), len(allow)},
+			{cfg.JSONDenyOut, jsonArrayBytes(deny), len(deny)},
+			{cfg.JSONAllowOut, jsonArrayBytes(allow), len(allow)},
+			{cfg.DenyMinOut, []byte(strings.Join(denyMin, "\n")), len(denyMin)},
+			{cfg.DenyWildcardsOut, []byte(strings.Join(wildcardRules, "\n")), len(wildcardRules)},
+		} {
+			if f.path == "" {
+				continue
+			}
+			sum := sha256.Sum256(f.content)
+			m.Files = append(m.Files, manifestFile{Path: f.path, SHA256: hex.EncodeToString(sum[:]), Entries: f.entries})
+		}
+
+		if cfg.DenyAutomatonOut != "" {
+			automaton, err := checker.New(append(append([]string{}, exactRules...), wildcardRules...))
+			if err != nil {
+				return fmt.Errorf("manifest: build checker automaton: %w", err)
+			}
+			var buf bytes.Buffer
+			if err := automaton.WriteGob(&buf); err != nil {
+				return fmt.Errorf("manifest: encode checker automaton: %w", err)
+			}
+			sum := sha256.Sum256(buf.Bytes())
+			m.Files = append(m.Files, manifestFile{
+				Path:    cfg.DenyAutomatonOut,
+				SHA256:  hex.EncodeToString(sum[:]),
+				Entries: len(exactRules) + len(wildcardRules),
+			})
+		}
+
+		b, err := json.MarshalIndent(m, "", "  ")
+		if err != nil {
+			return err
+		}
+		if err := ensureDir(filepath.Dir(cfg.ManifestOut)); err != nil {
+			return err
+		}
+		if err := os.WriteFile(cfg.ManifestOut, b, 0o644); err != nil {
+			return err
+		}
+
+		if key, ok, err := resolveSignKey(cfg.SignKeyEnv, cfg.SignKeyPath); err != nil {
+			return fmt.Errorf("load sign key: %w", err)
+		} else if ok {
+			sig := ed25519.Sign(key, b)
+			if err := os.WriteFile(cfg.ManifestOut+".sig", []byte(hex.EncodeToString(sig)), 0o644); err != nil {
+				return fmt.Errorf("write signature: %w", err)
+			}
+		}
+	}
+	return nil
+}
+
+// jsonArrayBytes renders arr exactly as writeJSON does, so the manifest
+// hashes the same bytes that land on disk. Marshaling a []string can't
+// fail, so the error is discarded.
+func jsonArrayBytes(arr []string) []byte {
+	b, _ := json.MarshalIndent(arr, "", "  ")
+	return b
+}
+
+// collapseToETLD1 reduces domains to their unique, sorted eTLD+1 set, for
+// consumers that don't need subdomain granularity.
+func collapseToETLD1(domains []string) []string {
+	out := make([]string, 0, len(domains))
+	for _, d := range domains {
+		et, err := effectiveTLDPlusOne(d)
+		if err != nil {
+			et = d
+		}
+		out = append(out, et)
+	}
+	return uniqueSorted(out)
+}
+
+// changelogMarkdown renders a human-readable diff between the previous and
+// current deny list, with added/removed domains grouped by eTLD+1.
+func changelogMarkdown(before, after []string) string {
+	added := difference(uniqueSorted(after), uniqueSorted(before))
+	removed := difference(uniqueSorted(before), uniqueSorted(after))
+
+	var b strings.Builder
+	fmt.Fprintf(&b, "# Deny list changes - %s\n\n", time.Now().Format("2006-01-02"))
+	writeChangeSection(&b, "Added", added)
+	writeChangeSection(&b, "Removed", removed)
+	if len(added) == 0 && len(removed) == 0 {
+		b.WriteString("No changes.\n")
+	}
+	return b.String()
+}
+
+func writeChangeSection(b *strings.Builder, title string, domains []string) {
+	if len(domains) == 0 {
+		return
+	}
+	fmt.Fprintf(b, "## %s (%d)\n\n", title, len(domains))
+	for _, group := range groupByETLD1(domains) {
+		fmt.Fprintf(b, "- **%s**\n", group.etld1)
+		for _, d := range group.domains {
+			fmt.Fprintf(b, "  - %s\n", d)
+		}
+	}
+	b.WriteString("\n")
+}
+
+type etld1Group struct {
+	etld1   string
+	domains []string
+}
+
+func groupByETLD1(domains []string) []etld1Group {
+	byETLD1 := make(map[string][]string)
+	for _, d := range domains {
+		et, err := effectiveTLDPlusOne(d)
+		if err != nil {
+			et = d
+		}
+		byETLD1[et] = append(byETLD1[et], d)
+	}
+	groups := make([]etld1Group, 0, len(byETLD1))
+	for et, ds := range byETLD1 {
+		sort.Strings(ds)
+		groups = append(groups, etld1Group{etld1: et, domains: ds})
+	}
+	sort.Slice(groups, func(i, j int) bool { return groups[i].etld1 < groups[j].etld1 })
+	return groups
+}
+
+// resolveSignKey loads a hex-encoded ed25519 private key from env or file,
+// in that order. ok is false (with no error) when neither is configured.
+func resolveSignKey(envName, path string) (key ed25519.PrivateKey, ok bool, err error) {
+	var hexKey string
+	switch {
+	case envName != "":
+		hexKey, ok = os.LookupEnv(envName)
+		if !ok {
+			return nil, false, fmt.Errorf("env var %q is not set", envName)
+		}
+	case path != "":
+		b, err := os.ReadFile(path)
+		if err != nil {
+			return nil, false, err
+		}
+		hexKey, ok = strings.TrimSpace(string(b)), true
+	default:
+		return nil, false, nil
+	}
+
+	raw, err := hex.DecodeString(hexKey)
+	if err != nil {
+		return nil, false, fmt.Errorf("decode hex key: %w", err)
+	}
+	if len(raw) != ed25519.PrivateKeySize {
+		return nil, false, fmt.Errorf("key must be %d bytes, got %d", ed25519.PrivateKeySize, len(raw))
+	}
+	return ed25519.PrivateKey(raw), true, nil
+}
+
+// readLines reads a newline-delimited file, returning nil (not an error)
+// if it doesn't exist yet - the common case for the very first run.
+func readLines(path string) ([]string, error) {
+	if path == "" {
+		return nil, nil
+	}
+	b, err := os.ReadFile(path)
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	var out []string
+	for _, line := range strings.Split(string(b), "\n") {
+		if line != "" {
+			out = append(out, line)
+		}
+	}
+	return out, nil
+}
@@ -0,0 +1,125 @@
+package generator
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/ilyasaftr/disposable-email-domains/pkg/checker"
+)
+
+func TestWriteReleaseArtifactsManifestRoundTrip(t *testing.T) {
+	dir := t.TempDir()
+
+	deny := []string{"bar.com", "foo.com"}
+	allow := []string{"good.com"}
+	denyWildcards := map[string]bool{"foo.com": true}
+
+	cfg := Config{
+		TextDenyOut:      filepath.Join(dir, "deny.txt"),
+		JSONDenyOut:      filepath.Join(dir, "deny.json"),
+		TextAllowOut:     filepath.Join(dir, "allow.txt"),
+		JSONAllowOut:     filepath.Join(dir, "allow.json"),
+		DenyWildcardsOut: filepath.Join(dir, "deny-wildcards.txt"),
+		DenyAutomatonOut: filepath.Join(dir, "deny.ac.bin"),
+		DenyMinOut:       filepath.Join(dir, "deny.min.txt"),
+		ManifestOut:      filepath.Join(dir, "manifest.json"),
+		ChangelogDir:     dir,
+	}
+
+	// writeReleaseArtifacts only computes the manifest from in-memory
+	// content; TextDenyOut/JSONDenyOut/TextAllowOut/JSONAllowOut and
+	// DenyWildcardsOut/DenyAutomatonOut are written by writeOutputs and
+	// writeWildcardOutputs respectively (generator.go), not by this
+	// function. Stage matching content on disk for all of them so the
+	// manifest-vs-disk hash comparison below is meaningful.
+	exactRules, wildcardRules := partitionWildcardRules(deny, denyWildcards)
+	mustWrite(t, cfg.TextDenyOut, []byte(strings.Join(deny, "\n")))
+	mustWrite(t, cfg.JSONDenyOut, jsonArrayBytes(deny))
+	mustWrite(t, cfg.TextAllowOut, []byte(strings.Join(allow, "\n")))
+	mustWrite(t, cfg.JSONAllowOut, jsonArrayBytes(allow))
+	mustWrite(t, cfg.DenyWildcardsOut, []byte(strings.Join(wildcardRules, "\n")))
+
+	automaton, err := checker.New(append(append([]string{}, exactRules...), wildcardRules...))
+	if err != nil {
+		t.Fatalf("checker.New: %v", err)
+	}
+	var automatonBuf bytes.Buffer
+	if err := automaton.WriteGob(&automatonBuf); err != nil {
+		t.Fatalf("WriteGob: %v", err)
+	}
+	mustWrite(t, cfg.DenyAutomatonOut, automatonBuf.Bytes())
+
+	previousDeny := []string{"bar.com"}
+	sourcesConsulted := []string{"https://example.com/deny.txt"}
+
+	if err := writeReleaseArtifacts(cfg, deny, allow, previousDeny, sourcesConsulted, denyWildcards); err != nil {
+		t.Fatalf("writeReleaseArtifacts: %v", err)
+	}
+
+	b, err := os.ReadFile(cfg.ManifestOut)
+	if err != nil {
+		t.Fatalf("read manifest: %v", err)
+	}
+	var m manifest
+	if err := json.Unmarshal(b, &m); err != nil {
+		t.Fatalf("decode manifest: %v", err)
+	}
+	if !equalStrings(m.SourcesConsulted, sourcesConsulted) {
+		t.Errorf("SourcesConsulted = %v, want %v", m.SourcesConsulted, sourcesConsulted)
+	}
+
+	wantPaths := []string{
+		cfg.TextDenyOut, cfg.TextAllowOut, cfg.JSONDenyOut, cfg.JSONAllowOut,
+		cfg.DenyMinOut, cfg.DenyWildcardsOut, cfg.DenyAutomatonOut,
+	}
+	gotPaths := make(map[string]manifestFile, len(m.Files))
+	for _, f := range m.Files {
+		gotPaths[f.Path] = f
+	}
+	for _, p := range wantPaths {
+		if _, ok := gotPaths[p]; !ok {
+			t.Errorf("manifest missing entry for %s", p)
+		}
+	}
+	if len(m.Files) != len(wantPaths) {
+		t.Errorf("manifest has %d files, want %d (%v)", len(m.Files), len(wantPaths), m.Files)
+	}
+
+	// Every manifest hash must match the bytes actually on disk for that
+	// path - the guarantee the manifest exists to provide.
+	for path, f := range gotPaths {
+		onDisk, err := os.ReadFile(path)
+		if err != nil {
+			t.Fatalf("read %s: %v", path, err)
+		}
+		sum := sha256.Sum256(onDisk)
+		if got := hex.EncodeToString(sum[:]); got != f.SHA256 {
+			t.Errorf("manifest SHA256 for %s = %s, want %s (actual file contents)", path, f.SHA256, got)
+		}
+	}
+
+	matches, err := filepath.Glob(filepath.Join(dir, "CHANGELOG-*.md"))
+	if err != nil || len(matches) != 1 {
+		t.Fatalf("glob changelog: matches=%v err=%v", matches, err)
+	}
+	changelog, err := os.ReadFile(matches[0])
+	if err != nil {
+		t.Fatalf("read changelog: %v", err)
+	}
+	if !strings.Contains(string(changelog), "foo.com") {
+		t.Errorf("changelog missing added domain foo.com:\n%s", changelog)
+	}
+}
+
+func mustWrite(t *testing.T, path string, content []byte) {
+	t.Helper()
+	if err := os.WriteFile(path, content, 0o644); err != nil {
+		t.Fatalf("write %s: %v", path, err)
+	}
+}
@@ -0,0 +1,278 @@
+// Package server runs the generator on a schedule and serves the result
+// over HTTP, so consumers can query the deny list without running the
+// generator themselves.
+package server
+
+import (
+	"context"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/ilyasaftr/disposable-email-domains/internal/generator"
+	"github.com/ilyasaftr/disposable-email-domains/pkg/checker"
+)
+
+// Config controls the serve daemon.
+type Config struct {
+	Addr      string        // HTTP listen address, e.g. ":8080"
+	Interval  time.Duration // how often to regenerate the lists
+	AuthToken string        // required bearer token for POST /v1/refresh; empty disables the endpoint
+
+	Generator generator.Config // passed straight through to generator.Run on each tick
+}
+
+// snapshot is the current, servable generation result.
+type snapshot struct {
+	matcher       *checker.Matcher
+	denyText      []byte
+	denyTextETag  string
+	denyJSON      []byte
+	denyJSONETag  string
+	version       string
+	generatedAt   time.Time
+	fetchDuration time.Duration
+	sourceFailed  int
+	denySize      int
+	allowSize     int
+}
+
+// Server holds the latest generation snapshot and serves it over HTTP.
+type Server struct {
+	cfg Config
+
+	// genMu serializes regenerate itself, not just the snapshot swap at its
+	// end: the scheduled tick loop and POST /v1/refresh both call it, and
+	// generator.Run writes the deny/allow output files and the validator
+	// state file in place, so two overlapping runs would read and write
+	// those same paths concurrently.
+	genMu sync.Mutex
+
+	mu    sync.RWMutex
+	state *snapshot // nil until the first successful generation
+}
+
+// New returns a Server for cfg. Call Run to start generating and serving.
+func New(cfg Config) *Server {
+	return &Server{cfg: cfg}
+}
+
+// Run performs an initial generation, then regenerates every cfg.Interval
+// and serves HTTP until ctx is canceled, at which point it shuts the HTTP
+// server down gracefully and returns.
+func (s *Server) Run(ctx context.Context) error {
+	if err := s.regenerate(ctx); err != nil {
+		log.Printf("initial generation failed: %v", err)
+	}
+
+	httpServer := &http.Server{Addr: s.cfg.Addr, Handler: s.routes()}
+	serveErr := make(chan error, 1)
+	go func() { serveErr <- httpServer.ListenAndServe() }()
+
+	ticker := time.NewTicker(s.cfg.Interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ticker.C:
+			if err := s.regenerate(ctx); err != nil {
+				log.Printf("scheduled generation failed: %v", err)
+			}
+		case err := <-serveErr:
+			if errors.Is(err, http.ErrServerClosed) {
+				return nil
+			}
+			return err
+		case <-ctx.Done():
+			shutdownCtx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+			defer cancel()
+			return httpServer.Shutdown(shutdownCtx)
+		}
+	}
+}
+
+func (s *Server) regenerate(ctx context.Context) error {
+	s.genMu.Lock()
+	defer s.genMu.Unlock()
+
+	start := time.Now()
+	denyCount, allowCount, stats, err := generator.Run(ctx, s.cfg.Generator)
+	duration := time.Since(start)
+	if err != nil {
+		// generator.Run returns partial results alongside soft errors (e.g.
+		// a handful of dead sources); only bail out if it couldn't write
+		// anything to serve.
+		log.Printf("generation completed with errors: %v", err)
+	}
+
+	denyText, rerr := os.ReadFile(s.cfg.Generator.TextDenyOut)
+	if rerr != nil {
+		return fmt.Errorf("read %s: %w", s.cfg.Generator.TextDenyOut, rerr)
+	}
+	denyJSON, rerr := os.ReadFile(s.cfg.Generator.JSONDenyOut)
+	if rerr != nil {
+		return fmt.Errorf("read %s: %w", s.cfg.Generator.JSONDenyOut, rerr)
+	}
+
+	rules := []string{string(denyText)}
+	if s.cfg.Generator.DenyWildcardsOut != "" {
+		if b, err := os.ReadFile(s.cfg.Generator.DenyWildcardsOut); err == nil {
+			rules = append(rules, string(b))
+		}
+	}
+	matcher, merr := checker.Load(strings.NewReader(strings.Join(rules, "\n")))
+	if merr != nil {
+		return fmt.Errorf("build matcher: %w", merr)
+	}
+
+	next := &snapshot{
+		matcher:       matcher,
+		denyText:      denyText,
+		denyTextETag:  hashETag(denyText),
+		denyJSON:      denyJSON,
+		denyJSONETag:  hashETag(denyJSON),
+		version:       hashETag(denyText)[:12],
+		generatedAt:   start,
+		fetchDuration: duration,
+		sourceFailed:  stats.Failed,
+		denySize:      denyCount,
+		allowSize:     allowCount,
+	}
+
+	s.mu.Lock()
+	s.state = next
+	s.mu.Unlock()
+	return nil
+}
+
+func (s *Server) snapshot() *snapshot {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.state
+}
+
+func hashETag(b []byte) string {
+	sum := sha256.Sum256(b)
+	return hex.EncodeToString(sum[:])
+}
+
+func (s *Server) routes() http.Handler {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/v1/check", s.handleCheck)
+	mux.HandleFunc("/v1/lists/deny.txt", s.handleDenyText)
+	mux.HandleFunc("/v1/lists/deny.json", s.handleDenyJSON)
+	mux.HandleFunc("/v1/metrics", s.handleMetrics)
+	mux.HandleFunc("/v1/refresh", s.handleRefresh)
+	mux.HandleFunc("/readyz", s.handleReady)
+	return mux
+}
+
+type checkResponse struct {
+	Disposable  bool   `json:"disposable"`
+	MatchedRule string `json:"matched_rule"`
+	ListVersion string `json:"list_version"`
+}
+
+func (s *Server) handleCheck(w http.ResponseWriter, r *http.Request) {
+	domain := r.URL.Query().Get("domain")
+	if domain == "" {
+		http.Error(w, "missing domain query parameter", http.StatusBadRequest)
+		return
+	}
+	snap := s.snapshot()
+	if snap == nil {
+		http.Error(w, "not ready", http.StatusServiceUnavailable)
+		return
+	}
+	rule, ok := snap.matcher.Match(domain)
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(checkResponse{Disposable: ok, MatchedRule: rule, ListVersion: snap.version})
+}
+
+func (s *Server) handleDenyText(w http.ResponseWriter, r *http.Request) {
+	snap := s.snapshot()
+	if snap == nil {
+		http.Error(w, "not ready", http.StatusServiceUnavailable)
+		return
+	}
+	serveCached(w, r, "text/plain; charset=utf-8", snap.denyTextETag, snap.denyText)
+}
+
+func (s *Server) handleDenyJSON(w http.ResponseWriter, r *http.Request) {
+	snap := s.snapshot()
+	if snap == nil {
+		http.Error(w, "not ready", http.StatusServiceUnavailable)
+		return
+	}
+	serveCached(w, r, "application/json", snap.denyJSONETag, snap.denyJSON)
+}
+
+func serveCached(w http.ResponseWriter, r *http.Request, contentType, etag string, body []byte) {
+	w.Header().Set("ETag", etag)
+	if r.Header.Get("If-None-Match") == etag {
+		w.WriteHeader(http.StatusNotModified)
+		return
+	}
+	w.Header().Set("Content-Type", contentType)
+	w.Write(body)
+}
+
+func (s *Server) handleMetrics(w http.ResponseWriter, r *http.Request) {
+	snap := s.snapshot()
+	w.Header().Set("Content-Type", "text/plain; version=0.0.4")
+	if snap == nil {
+		return
+	}
+	fmt.Fprintf(w, "# HELP disposable_email_domains_fetch_duration_seconds Duration of the last generation run.\n")
+	fmt.Fprintf(w, "# TYPE disposable_email_domains_fetch_duration_seconds gauge\n")
+	fmt.Fprintf(w, "disposable_email_domains_fetch_duration_seconds %f\n", snap.fetchDuration.Seconds())
+	fmt.Fprintf(w, "# HELP disposable_email_domains_source_failures_total Failed source fetches in the last generation run.\n")
+	fmt.Fprintf(w, "# TYPE disposable_email_domains_source_failures_total gauge\n")
+	fmt.Fprintf(w, "disposable_email_domains_source_failures_total %d\n", snap.sourceFailed)
+	fmt.Fprintf(w, "# HELP disposable_email_domains_deny_size Number of entries in the deny list.\n")
+	fmt.Fprintf(w, "# TYPE disposable_email_domains_deny_size gauge\n")
+	fmt.Fprintf(w, "disposable_email_domains_deny_size %d\n", snap.denySize)
+	fmt.Fprintf(w, "# HELP disposable_email_domains_allow_size Number of entries in the allow list.\n")
+	fmt.Fprintf(w, "# TYPE disposable_email_domains_allow_size gauge\n")
+	fmt.Fprintf(w, "disposable_email_domains_allow_size %d\n", snap.allowSize)
+}
+
+// constantTimeEqual reports whether got and want are equal, without
+// branching on the content of either, so comparing the refresh auth token
+// doesn't leak how many leading bytes matched via timing.
+func constantTimeEqual(got, want string) bool {
+	return subtle.ConstantTimeCompare([]byte(got), []byte(want)) == 1
+}
+
+func (s *Server) handleRefresh(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	if s.cfg.AuthToken == "" || !constantTimeEqual(r.Header.Get("Authorization"), "Bearer "+s.cfg.AuthToken) {
+		http.Error(w, "unauthorized", http.StatusUnauthorized)
+		return
+	}
+	if err := s.regenerate(r.Context()); err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	w.WriteHeader(http.StatusOK)
+}
+
+func (s *Server) handleReady(w http.ResponseWriter, r *http.Request) {
+	if s.snapshot() == nil {
+		http.Error(w, "not ready", http.StatusServiceUnavailable)
+		return
+	}
+	w.WriteHeader(http.StatusOK)
+}
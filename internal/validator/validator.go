@@ -0,0 +1,283 @@
+// Package validator prunes disposable-domain candidates that no longer
+// resolve, so stale entries don't accumulate in the deny list forever.
+package validator
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+)
+
+// Config controls how candidate domains are DNS/MX-validated.
+type Config struct {
+	// Resolvers is a list of "host:port" DNS resolvers to query. Empty uses
+	// the system resolver.
+	Resolvers []string
+	// Concurrency bounds how many domains are checked in parallel. Defaults
+	// to 20 when <= 0.
+	Concurrency int
+	// DeadRunsThreshold is how many consecutive runs a domain must resolve
+	// as dead before it is reported as dead.
+	DeadRunsThreshold int
+	// StatePath is where the consecutive-dead-run counters persist between
+	// runs. Empty disables persistence (every run starts from a clean
+	// slate).
+	StatePath string
+	// CacheTTL is how long a prior lookup result is trusted before a
+	// domain is re-checked. 0 disables result caching.
+	CacheTTL time.Duration
+}
+
+// Outcome is the per-domain result of a validation pass.
+type Outcome struct {
+	Domain              string
+	Alive               bool
+	ConsecutiveDeadRuns int
+	Dead                bool // ConsecutiveDeadRuns >= cfg.DeadRunsThreshold
+}
+
+// domainState is the persisted, per-domain record in the state file.
+type domainState struct {
+	LastChecked         time.Time `json:"last_checked"`
+	LastAlive           bool      `json:"last_alive"`
+	ConsecutiveDeadRuns int       `json:"consecutive_dead_runs"`
+}
+
+type state struct {
+	Domains map[string]domainState `json:"domains"`
+}
+
+func loadState(path string) (*state, error) {
+	if path == "" {
+		return &state{Domains: map[string]domainState{}}, nil
+	}
+	b, err := os.ReadFile(path)
+	if os.IsNotExist(err) {
+		return &state{Domains: map[string]domainState{}}, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	var s state
+	if err := json.Unmarshal(b, &s); err != nil {
+		return nil, fmt.Errorf("decode state: %w", err)
+	}
+	if s.Domains == nil {
+		s.Domains = map[string]domainState{}
+	}
+	return &s, nil
+}
+
+func (s *state) save(path string) error {
+	if path == "" {
+		return nil
+	}
+	if dir := filepath.Dir(path); dir != "." && dir != "" {
+		if err := os.MkdirAll(dir, 0o755); err != nil {
+			return err
+		}
+	}
+	b, err := json.MarshalIndent(s, "", "  ")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(path, b, 0o644)
+}
+
+// Run resolves each domain's A/AAAA/MX records with bounded concurrency,
+// updates the persistent dead-run counters, and returns the domains still
+// considered alive, the ones now classified dead, and the full per-domain
+// outcome list.
+func Run(ctx context.Context, domains []string, cfg Config) (alive, dead []string, outcomes []Outcome, err error) {
+	concurrency := cfg.Concurrency
+	if concurrency <= 0 {
+		concurrency = 20
+	}
+	threshold := cfg.DeadRunsThreshold
+	if threshold <= 0 {
+		threshold = 1
+	}
+
+	st, err := loadState(cfg.StatePath)
+	if err != nil {
+		return nil, nil, nil, err
+	}
+
+	resolver := newResolver(cfg.Resolvers)
+	outcomes = make([]Outcome, len(domains))
+
+	var mu sync.Mutex
+	var wg sync.WaitGroup
+	sem := make(chan struct{}, concurrency)
+
+	for i, domain := range domains {
+		wg.Add(1)
+		go func(i int, domain string) {
+			defer wg.Done()
+			select {
+			case sem <- struct{}{}:
+			case <-ctx.Done():
+				return
+			}
+			defer func() { <-sem }()
+
+			mu.Lock()
+			prev, hadPrev := st.Domains[domain]
+			mu.Unlock()
+
+			var isAlive, confirmed, lookedUp bool
+			if hadPrev && cfg.CacheTTL > 0 && time.Since(prev.LastChecked) < cfg.CacheTTL {
+				isAlive, confirmed = prev.LastAlive, true
+			} else {
+				isAlive, confirmed = lookupWithBackoff(ctx, resolver, domain)
+				lookedUp = true
+			}
+
+			next, outcome := nextDomainState(domain, prev, hadPrev, isAlive, confirmed, lookedUp, threshold)
+
+			mu.Lock()
+			st.Domains[domain] = next
+			mu.Unlock()
+
+			outcomes[i] = outcome
+		}(i, domain)
+	}
+	wg.Wait()
+
+	if err := ctx.Err(); err != nil {
+		return nil, nil, nil, err
+	}
+
+	for _, o := range outcomes {
+		if o.Dead {
+			dead = append(dead, o.Domain)
+		} else {
+			alive = append(alive, o.Domain)
+		}
+	}
+
+	if serr := st.save(cfg.StatePath); serr != nil {
+		return alive, dead, outcomes, serr
+	}
+	return alive, dead, outcomes, nil
+}
+
+// nextDomainState computes the persisted state and reported Outcome for a
+// single domain, given its prior state (prev, hadPrev) and this run's
+// lookup result. isAlive/confirmed follow lookupWithBackoff's contract:
+// confirmed is false when every attempt was indeterminate (resolvers
+// unreachable, timeouts, etc.), in which case the prior dead-run count is
+// carried forward unchanged rather than counted as either a live or a dead
+// run, so a transient resolver outage can't prune the whole deny list.
+// lookedUp is false when isAlive/confirmed came from the CacheTTL shortcut
+// rather than a real lookup; LastChecked is only bumped when lookedUp, so a
+// cached result can't keep renewing its own TTL and never be re-validated.
+// A cache hit also just replays the prior verdict rather than reconfirming
+// it, so it carries ConsecutiveDeadRuns forward unchanged instead of
+// incrementing it again - otherwise a single confirmed-dead lookup would get
+// amplified into threshold-crossing "consecutive" dead runs purely by being
+// replayed from cache on later ticks.
+func nextDomainState(domain string, prev domainState, hadPrev bool, isAlive, confirmed, lookedUp bool, threshold int) (domainState, Outcome) {
+	next := domainState{LastChecked: prev.LastChecked, LastAlive: isAlive}
+	if lookedUp {
+		next.LastChecked = time.Now()
+	}
+	switch {
+	case !confirmed:
+		isAlive = hadPrev && prev.LastAlive
+		next.LastAlive = isAlive
+		if hadPrev {
+			next.ConsecutiveDeadRuns = prev.ConsecutiveDeadRuns
+		}
+	case !lookedUp:
+		next.ConsecutiveDeadRuns = prev.ConsecutiveDeadRuns
+	case isAlive:
+		next.ConsecutiveDeadRuns = 0
+	case hadPrev:
+		next.ConsecutiveDeadRuns = prev.ConsecutiveDeadRuns + 1
+	default:
+		next.ConsecutiveDeadRuns = 1
+	}
+	return next, Outcome{
+		Domain:              domain,
+		Alive:               isAlive,
+		ConsecutiveDeadRuns: next.ConsecutiveDeadRuns,
+		Dead:                next.ConsecutiveDeadRuns >= threshold,
+	}
+}
+
+// newResolver builds a net.Resolver that queries the given DNS servers
+// instead of the system default, when any are configured.
+func newResolver(resolvers []string) *net.Resolver {
+	if len(resolvers) == 0 {
+		return net.DefaultResolver
+	}
+	var next int
+	var mu sync.Mutex
+	return &net.Resolver{
+		PreferGo: true,
+		Dial: func(ctx context.Context, network, address string) (net.Conn, error) {
+			mu.Lock()
+			addr := resolvers[next%len(resolvers)]
+			next++
+			mu.Unlock()
+			var d net.Dialer
+			return d.DialContext(ctx, network, addr)
+		},
+	}
+}
+
+// lookupWithBackoff checks whether domain has any A/AAAA or MX records,
+// retrying transient failures with exponential backoff across up to three
+// attempts (and, when multiple resolvers are configured, a different
+// resolver each attempt via newResolver's round robin). confirmed is false
+// when every attempt errored, meaning isAlive carries no information.
+func lookupWithBackoff(ctx context.Context, resolver *net.Resolver, domain string) (isAlive, confirmed bool) {
+	for attempt := 0; attempt < 3; attempt++ {
+		if alive, err := lookup(ctx, resolver, domain); err == nil {
+			return alive, true
+		}
+		select {
+		case <-time.After(time.Duration(1<<attempt) * time.Second):
+		case <-ctx.Done():
+			return false, false
+		}
+	}
+	return false, false
+}
+
+// lookup reports whether domain has any A/AAAA or MX records. A definitive
+// NXDOMAIN (no such host) on both lookups is treated as dead; any other
+// error is surfaced so the caller can retry.
+func lookup(ctx context.Context, resolver *net.Resolver, domain string) (bool, error) {
+	_, hostErr := resolver.LookupHost(ctx, domain)
+	if hostErr == nil {
+		return true, nil
+	}
+	if !isNXDomain(hostErr) {
+		return false, hostErr
+	}
+
+	mxRecords, mxErr := resolver.LookupMX(ctx, domain)
+	if mxErr == nil {
+		return len(mxRecords) > 0, nil
+	}
+	if !isNXDomain(mxErr) {
+		return false, mxErr
+	}
+	return false, nil
+}
+
+func isNXDomain(err error) bool {
+	var dnsErr *net.DNSError
+	if errors.As(err, &dnsErr) {
+		return dnsErr.IsNotFound
+	}
+	return false
+}
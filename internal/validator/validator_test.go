@@ -0,0 +1,223 @@
+package validator
+
+import (
+	"context"
+	"fmt"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+var fixedPast = time.Now().Add(-24 * time.Hour)
+
+func TestNextDomainState(t *testing.T) {
+	tests := []struct {
+		name        string
+		prev        domainState
+		hadPrev     bool
+		isAlive     bool
+		confirmed   bool
+		lookedUp    bool
+		threshold   int
+		wantAlive   bool
+		wantDead    int
+		wantOutcome bool // Outcome.Dead
+	}{
+		{
+			name:      "fresh domain confirmed alive",
+			hadPrev:   false,
+			isAlive:   true,
+			confirmed: true,
+			lookedUp:  true,
+			threshold: 3,
+			wantAlive: true,
+			wantDead:  0,
+		},
+		{
+			name:      "fresh domain confirmed dead",
+			hadPrev:   false,
+			isAlive:   false,
+			confirmed: true,
+			lookedUp:  true,
+			threshold: 3,
+			wantAlive: false,
+			wantDead:  1,
+		},
+		{
+			name:      "confirmed alive resets the dead-run counter",
+			prev:      domainState{LastAlive: false, ConsecutiveDeadRuns: 2},
+			hadPrev:   true,
+			isAlive:   true,
+			confirmed: true,
+			lookedUp:  true,
+			threshold: 3,
+			wantAlive: true,
+			wantDead:  0,
+		},
+		{
+			name:      "confirmed dead increments the counter",
+			prev:      domainState{LastAlive: false, ConsecutiveDeadRuns: 2},
+			hadPrev:   true,
+			isAlive:   false,
+			confirmed: true,
+			lookedUp:  true,
+			threshold: 5,
+			wantAlive: false,
+			wantDead:  3,
+		},
+		{
+			name:        "counter crossing threshold is reported dead",
+			prev:        domainState{LastAlive: false, ConsecutiveDeadRuns: 2},
+			hadPrev:     true,
+			isAlive:     false,
+			confirmed:   true,
+			lookedUp:    true,
+			threshold:   3,
+			wantAlive:   false,
+			wantDead:    3,
+			wantOutcome: true,
+		},
+		{
+			name:      "indeterminate with no prior state is not alive and not a dead run",
+			hadPrev:   false,
+			isAlive:   false,
+			confirmed: false,
+			lookedUp:  true,
+			threshold: 1,
+			wantAlive: false,
+			wantDead:  0,
+		},
+		{
+			name:      "indeterminate carries forward prior alive state",
+			prev:      domainState{LastAlive: true, ConsecutiveDeadRuns: 0},
+			hadPrev:   true,
+			isAlive:   false, // lookupWithBackoff's zero value when confirmed is false
+			confirmed: false,
+			lookedUp:  true,
+			threshold: 1,
+			wantAlive: true,
+			wantDead:  0,
+		},
+		{
+			name:      "indeterminate carries forward prior dead-run count unchanged",
+			prev:      domainState{LastAlive: false, ConsecutiveDeadRuns: 2},
+			hadPrev:   true,
+			isAlive:   false,
+			confirmed: false,
+			lookedUp:  true,
+			threshold: 3,
+			wantAlive: false,
+			wantDead:  2,
+		},
+		{
+			name:        "indeterminate never pushes a domain past the threshold",
+			prev:        domainState{LastAlive: false, ConsecutiveDeadRuns: 3},
+			hadPrev:     true,
+			isAlive:     false,
+			confirmed:   false,
+			lookedUp:    true,
+			threshold:   3,
+			wantAlive:   false,
+			wantDead:    3,
+			wantOutcome: true, // was already at/over threshold before this run
+		},
+		{
+			name:      "cache hit does not bump LastChecked",
+			prev:      domainState{LastChecked: fixedPast, LastAlive: true, ConsecutiveDeadRuns: 0},
+			hadPrev:   true,
+			isAlive:   true,
+			confirmed: true,
+			lookedUp:  false,
+			threshold: 3,
+			wantAlive: true,
+			wantDead:  0,
+		},
+		{
+			name:      "cache hit replays the prior verdict without incrementing the dead-run counter",
+			prev:      domainState{LastChecked: fixedPast, LastAlive: false, ConsecutiveDeadRuns: 1},
+			hadPrev:   true,
+			isAlive:   false,
+			confirmed: true,
+			lookedUp:  false,
+			threshold: 3,
+			wantAlive: false,
+			wantDead:  1,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			next, outcome := nextDomainState("evil.com", tt.prev, tt.hadPrev, tt.isAlive, tt.confirmed, tt.lookedUp, tt.threshold)
+
+			if next.LastAlive != tt.wantAlive {
+				t.Errorf("next.LastAlive = %v, want %v", next.LastAlive, tt.wantAlive)
+			}
+			if next.ConsecutiveDeadRuns != tt.wantDead {
+				t.Errorf("next.ConsecutiveDeadRuns = %d, want %d", next.ConsecutiveDeadRuns, tt.wantDead)
+			}
+			if outcome.Domain != "evil.com" {
+				t.Errorf("outcome.Domain = %q, want %q", outcome.Domain, "evil.com")
+			}
+			if outcome.Alive != tt.wantAlive {
+				t.Errorf("outcome.Alive = %v, want %v", outcome.Alive, tt.wantAlive)
+			}
+			if outcome.ConsecutiveDeadRuns != tt.wantDead {
+				t.Errorf("outcome.ConsecutiveDeadRuns = %d, want %d", outcome.ConsecutiveDeadRuns, tt.wantDead)
+			}
+			if outcome.Dead != tt.wantOutcome {
+				t.Errorf("outcome.Dead = %v, want %v", outcome.Dead, tt.wantOutcome)
+			}
+			if tt.lookedUp {
+				if time.Since(next.LastChecked) > time.Minute {
+					t.Errorf("next.LastChecked = %v, want ~now", next.LastChecked)
+				}
+			} else if !next.LastChecked.Equal(tt.prev.LastChecked) {
+				t.Errorf("next.LastChecked = %v, want unchanged prev.LastChecked %v", next.LastChecked, tt.prev.LastChecked)
+			}
+		})
+	}
+}
+
+// TestRunConcurrentBookkeepingIsolatedPerDomain exercises Run's concurrent
+// map writes across many domains, all served from the result cache (so no
+// real DNS traffic or backoff is involved), and checks each domain's
+// updated state matches what nextDomainState alone would have produced.
+func TestRunConcurrentBookkeepingIsolatedPerDomain(t *testing.T) {
+	statePath := filepath.Join(t.TempDir(), "state.json")
+
+	const n = 50
+	domains := make([]string, n)
+	seed := map[string]domainState{}
+	for i := 0; i < n; i++ {
+		d := fmt.Sprintf("domain%d.example", i)
+		domains[i] = d
+		seed[d] = domainState{LastChecked: time.Now(), LastAlive: i%2 == 0, ConsecutiveDeadRuns: i % 4}
+	}
+	st := &state{Domains: seed}
+	if err := st.save(statePath); err != nil {
+		t.Fatalf("seed state: %v", err)
+	}
+
+	cfg := Config{StatePath: statePath, CacheTTL: time.Hour, Concurrency: 10, DeadRunsThreshold: 3}
+	_, _, outcomes, err := Run(context.Background(), domains, cfg)
+	if err != nil {
+		t.Fatalf("Run: %v", err)
+	}
+
+	for i, o := range outcomes {
+		d := domains[i]
+		want := seed[d]
+		// Every domain hits the CacheTTL shortcut, which just replays the
+		// seeded verdict (nextDomainState's !lookedUp branch) rather than
+		// reconfirming it, so ConsecutiveDeadRuns carries forward unchanged.
+		// The point of this test is that concurrent map writes don't
+		// cross-contaminate between domains, not the bookkeeping arithmetic
+		// itself (covered by TestNextDomainState).
+		if o.Alive != want.LastAlive {
+			t.Errorf("domain %s: Alive = %v, want %v", d, o.Alive, want.LastAlive)
+		}
+		if o.ConsecutiveDeadRuns != want.ConsecutiveDeadRuns {
+			t.Errorf("domain %s: ConsecutiveDeadRuns = %d, want %d", d, o.ConsecutiveDeadRuns, want.ConsecutiveDeadRuns)
+		}
+	}
+}
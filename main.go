@@ -6,9 +6,10 @@ import (
 	"fmt"
 	"log"
 	"os"
+	"strings"
 	"time"
 
-    "github.com/ilyasaftr/disposable-email-domains/internal/generator"
+	"github.com/ilyasaftr/disposable-email-domains/internal/generator"
 )
 
 func main() {
@@ -27,6 +28,28 @@ func main() {
 	jsonAllowOut := flag.String("out-json-allow", "lists/allow.json", "output path for JSON allow list")
 
 	timeout := flag.Duration("timeout", 5*time.Minute, "overall timeout")
+	concurrency := flag.Int("concurrency", 8, "max number of sources to fetch in parallel")
+	perHostRPS := flag.Float64("per-host-rps", 2, "max requests per second to a single host")
+	cacheDir := flag.String("cache-dir", ".cache/sources", "on-disk HTTP cache directory (empty disables caching)")
+	cacheTTL := flag.Duration("cache-ttl", time.Hour, "how long a cached source is served without revalidation")
+
+	validateDNS := flag.Bool("validate-dns", false, "prune deny domains that fail DNS/MX validation")
+	resolvers := flag.String("resolvers", "", "comma-separated list of host:port DNS resolvers (empty uses the system resolver)")
+	validateConcurrency := flag.Int("validate-concurrency", 20, "max number of domains to DNS-validate in parallel")
+	deadRunsThreshold := flag.Int("dead-runs-threshold", 3, "consecutive dead runs before a domain is reported as dead")
+	statePath := flag.String("state", ".cache/validator-state.json", "path to the DNS validation state file")
+	validateCacheTTL := flag.Duration("validate-cache-ttl", 0, "how long a prior DNS/MX result is trusted before a domain is re-checked (0 disables caching)")
+	pruneDead := flag.Bool("prune-dead", false, "remove domains classified dead from the deny list output")
+	denyDeadOut := flag.String("out-deny-dead", "lists/deny-dead.txt", "output path for domains classified dead by DNS validation")
+
+	denyWildcardsOut := flag.String("out-deny-wildcards", "lists/deny-wildcards.txt", "output path for *.base.com wildcard deny rules")
+	denyAutomatonOut := flag.String("out-deny-automaton", "lists/deny.ac.bin", "output path for the gob-encoded pkg/checker automaton")
+
+	manifestOut := flag.String("out-manifest", "lists/manifest.json", "output path for the release manifest (empty disables it)")
+	changelogDir := flag.String("changelog-dir", "lists", "directory to write CHANGELOG-<date>.md into (empty disables it)")
+	denyMinOut := flag.String("out-deny-min", "lists/deny.min.txt", "output path for the eTLD+1-collapsed deny list")
+	signKeyEnv := flag.String("sign-key-env", "", "env var holding a hex-encoded ed25519 private key to sign the manifest with")
+	signKeyFile := flag.String("sign-key", "", "path to a file holding a hex-encoded ed25519 private key to sign the manifest with")
 	flag.Parse()
 
 	must := func(b []byte, err error) []byte {
@@ -42,6 +65,10 @@ func main() {
 		}
 		return splitLines(string(b))
 	}
+	var resolverList []string
+	if *resolvers != "" {
+		resolverList = strings.Split(*resolvers, ",")
+	}
 
 	cfg := generator.Config{
 		TextDeny:     splitLines(string(must(os.ReadFile(*textDenyPath)))),
@@ -53,6 +80,28 @@ func main() {
 		JSONDenyOut:  *jsonDenyOut,
 		TextAllowOut: *textAllowOut,
 		JSONAllowOut: *jsonAllowOut,
+		Concurrency:  *concurrency,
+		PerHostRPS:   *perHostRPS,
+		CacheDir:     *cacheDir,
+		CacheTTL:     *cacheTTL,
+
+		ValidateDNS:         *validateDNS,
+		Resolvers:           resolverList,
+		ValidateConcurrency: *validateConcurrency,
+		DeadRunsThreshold:   *deadRunsThreshold,
+		StatePath:           *statePath,
+		ValidateCacheTTL:    *validateCacheTTL,
+		PruneDeadDomains:    *pruneDead,
+		DenyDeadOut:         *denyDeadOut,
+
+		DenyWildcardsOut: *denyWildcardsOut,
+		DenyAutomatonOut: *denyAutomatonOut,
+
+		ManifestOut:  *manifestOut,
+		ChangelogDir: *changelogDir,
+		DenyMinOut:   *denyMinOut,
+		SignKeyEnv:   *signKeyEnv,
+		SignKeyPath:  *signKeyFile,
 	}
 
 	ctx, cancel := context.WithTimeout(context.Background(), *timeout)
@@ -60,11 +109,18 @@ func main() {
 
 	start := time.Now()
 	log.Println("Generating allow/deny domain files…")
-	d, a, err := generator.Run(ctx, cfg)
+	d, a, stats, err := generator.Run(ctx, cfg)
 	if err != nil {
 		fmt.Fprintf(os.Stderr, "warning: %v\n", err)
 	}
-	log.Printf("Done in %s. deny=%d allow=%d", time.Since(start).Truncate(time.Millisecond), d, a)
+	log.Printf("Done in %s. deny=%d allow=%d fetch(hit=%d miss=%d not-modified=%d failed=%d)",
+		time.Since(start).Truncate(time.Millisecond), d, a,
+		stats.Hits, stats.Misses, stats.NotModified, stats.Failed)
+	for url, res := range stats.PerSource {
+		if res.Status == "failed" {
+			log.Printf("source failed: %s: %s", url, res.Error)
+		}
+	}
 }
 
 func splitLines(s string) []string {
@@ -0,0 +1,164 @@
+// Package checker provides a queryable, standalone API for matching email
+// addresses and domains against a disposable-domain rule set, including
+// "*.base.com" wildcard rules that also cover their subdomains.
+package checker
+
+import (
+	"bufio"
+	"encoding/gob"
+	"fmt"
+	"io"
+	"strings"
+)
+
+// Matcher matches domains and emails against a set of exact and wildcard
+// disposable-domain rules.
+type Matcher struct {
+	exact map[string]struct{}
+	root  *node
+
+	// exactRules and wildcardRules are the original rule strings, retained
+	// so WriteGob can round-trip without re-deriving them from the trie.
+	exactRules    []string
+	wildcardRules []string
+}
+
+// node is a trie node keyed by one reversed domain label at a time (e.g.
+// matching "evil.com" walks "com" then "evil"). Because we only ever need
+// the longest matching *suffix* of the query domain, a plain trie walk is
+// enough here - there's no need for Aho-Corasick failure links, which exist
+// to resume a search after a partial substring match fails anywhere in the
+// text. A single left-to-right pass over the reversed labels yields the
+// longest matching rule in O(len(host)).
+type node struct {
+	children map[string]*node
+	wildcard bool
+	rule     string // original "*.base.com" rule text, set when wildcard is true
+}
+
+// New builds a Matcher from rule lines, each either an exact domain
+// ("evil.com") or a wildcard rule ("*.evil.com", matching evil.com and all
+// of its subdomains). Blank lines and "#" comments are ignored.
+func New(rules []string) (*Matcher, error) {
+	m := &Matcher{
+		exact: make(map[string]struct{}),
+		root:  &node{children: make(map[string]*node)},
+	}
+	for _, rule := range rules {
+		rule = strings.ToLower(strings.TrimSpace(rule))
+		if rule == "" || strings.HasPrefix(rule, "#") {
+			continue
+		}
+		if base, ok := strings.CutPrefix(rule, "*."); ok {
+			if base == "" {
+				return nil, fmt.Errorf("invalid wildcard rule %q: empty base domain", rule)
+			}
+			m.insertWildcard(base, rule)
+			m.wildcardRules = append(m.wildcardRules, rule)
+			continue
+		}
+		m.exact[rule] = struct{}{}
+		m.exactRules = append(m.exactRules, rule)
+	}
+	return m, nil
+}
+
+// Load reads newline-delimited rules from r and builds a Matcher. See New
+// for the accepted rule syntax.
+func Load(r io.Reader) (*Matcher, error) {
+	var rules []string
+	scanner := bufio.NewScanner(r)
+	for scanner.Scan() {
+		rules = append(rules, scanner.Text())
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	return New(rules)
+}
+
+func (m *Matcher) insertWildcard(base, rule string) {
+	n := m.root
+	for _, label := range reversedLabels(base) {
+		child, ok := n.children[label]
+		if !ok {
+			child = &node{children: make(map[string]*node)}
+			n.children[label] = child
+		}
+		n = child
+	}
+	n.wildcard = true
+	n.rule = rule
+}
+
+// Match reports the rule that matches domain, if any. An exact rule always
+// wins over a wildcard rule; among wildcard rules, the most specific (i.e.
+// longest base domain) wins.
+func (m *Matcher) Match(domain string) (rule string, ok bool) {
+	domain = strings.ToLower(strings.TrimSuffix(strings.TrimSpace(domain), "."))
+	if domain == "" {
+		return "", false
+	}
+	if _, hit := m.exact[domain]; hit {
+		return domain, true
+	}
+
+	n := m.root
+	for _, label := range reversedLabels(domain) {
+		child, exists := n.children[label]
+		if !exists {
+			break
+		}
+		n = child
+		if n.wildcard {
+			rule, ok = n.rule, true
+		}
+	}
+	return rule, ok
+}
+
+// IsDisposable reports whether email's domain part matches a rule in m.
+func (m *Matcher) IsDisposable(email string) bool {
+	at := strings.LastIndexByte(email, '@')
+	if at < 0 || at == len(email)-1 {
+		return false
+	}
+	_, ok := m.Match(email[at+1:])
+	return ok
+}
+
+func reversedLabels(domain string) []string {
+	labels := strings.Split(domain, ".")
+	for i, j := 0, len(labels)-1; i < j; i, j = i+1, j-1 {
+		labels[i], labels[j] = labels[j], labels[i]
+	}
+	return labels
+}
+
+// gobSnapshot is the on-disk form written by WriteGob. Rather than
+// serializing the trie's pointer graph directly, it records the original
+// rule strings and lets LoadGob rebuild the trie via New - the rule sets
+// involved are small enough that re-deriving the trie is cheap, and this
+// keeps the binary format trivial to evolve.
+type gobSnapshot struct {
+	Exact     []string
+	Wildcards []string
+}
+
+// WriteGob serializes m so it can be restored with LoadGob without
+// downstream consumers needing to re-parse or re-clean the source text.
+func (m *Matcher) WriteGob(w io.Writer) error {
+	return gob.NewEncoder(w).Encode(gobSnapshot{Exact: m.exactRules, Wildcards: m.wildcardRules})
+}
+
+// LoadGob restores a Matcher previously serialized with WriteGob.
+func LoadGob(r io.Reader) (*Matcher, error) {
+	var snap gobSnapshot
+	if err := gob.NewDecoder(r).Decode(&snap); err != nil {
+		return nil, err
+	}
+	rules := make([]string, 0, len(snap.Exact)+len(snap.Wildcards))
+	rules = append(rules, snap.Exact...)
+	rules = append(rules, snap.Wildcards...)
+	return New(rules)
+}
@@ -0,0 +1,109 @@
+package checker
+
+import (
+	"bytes"
+	"testing"
+)
+
+func TestMatchPrecedence(t *testing.T) {
+	m, err := New([]string{
+		"evil.com",
+		"*.evil.com",
+		"*.mail.evil.com",
+		"*.base.com",
+	})
+	if err != nil {
+		t.Fatalf("New: %v", err)
+	}
+
+	tests := []struct {
+		name   string
+		domain string
+		rule   string
+		ok     bool
+	}{
+		{"exact beats wildcard", "evil.com", "evil.com", true},
+		{"wildcard matches subdomain", "foo.evil.com", "*.evil.com", true},
+		{"longest wildcard suffix wins", "spam.mail.evil.com", "*.mail.evil.com", true},
+		{"shorter wildcard still matches deeper subdomain", "a.b.mail.evil.com", "*.mail.evil.com", true},
+		{"unrelated base wildcard", "sub.base.com", "*.base.com", true},
+		{"no match", "example.com", "", false},
+		{"case-insensitive", "EVIL.COM", "evil.com", true},
+		{"trailing dot ignored", "evil.com.", "evil.com", true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rule, ok := m.Match(tt.domain)
+			if ok != tt.ok || rule != tt.rule {
+				t.Errorf("Match(%q) = (%q, %v), want (%q, %v)", tt.domain, rule, ok, tt.rule, tt.ok)
+			}
+		})
+	}
+}
+
+func TestIsDisposable(t *testing.T) {
+	m, err := New([]string{"evil.com", "*.spam.net"})
+	if err != nil {
+		t.Fatalf("New: %v", err)
+	}
+
+	tests := []struct {
+		email string
+		want  bool
+	}{
+		{"user@evil.com", true},
+		{"user@sub.spam.net", true},
+		{"user@good.com", false},
+		{"not-an-email", false},
+		{"trailing-at@", false},
+	}
+	for _, tt := range tests {
+		if got := m.IsDisposable(tt.email); got != tt.want {
+			t.Errorf("IsDisposable(%q) = %v, want %v", tt.email, got, tt.want)
+		}
+	}
+}
+
+func TestNewRejectsEmptyWildcardBase(t *testing.T) {
+	if _, err := New([]string{"*."}); err == nil {
+		t.Fatal("New([]string{\"*.\"}) succeeded, want error")
+	}
+}
+
+func TestNewSkipsBlankLinesAndComments(t *testing.T) {
+	m, err := New([]string{"", "  ", "# a comment", "evil.com"})
+	if err != nil {
+		t.Fatalf("New: %v", err)
+	}
+	if _, ok := m.Match("evil.com"); !ok {
+		t.Fatal("Match(\"evil.com\") = false, want true")
+	}
+	if _, ok := m.Match("# a comment"); ok {
+		t.Fatal("comment line was inserted as a rule")
+	}
+}
+
+func TestGobRoundTrip(t *testing.T) {
+	m, err := New([]string{"evil.com", "*.spam.net"})
+	if err != nil {
+		t.Fatalf("New: %v", err)
+	}
+
+	var buf bytes.Buffer
+	if err := m.WriteGob(&buf); err != nil {
+		t.Fatalf("WriteGob: %v", err)
+	}
+
+	restored, err := LoadGob(&buf)
+	if err != nil {
+		t.Fatalf("LoadGob: %v", err)
+	}
+
+	for _, domain := range []string{"evil.com", "sub.spam.net", "example.com"} {
+		wantRule, wantOK := m.Match(domain)
+		gotRule, gotOK := restored.Match(domain)
+		if gotRule != wantRule || gotOK != wantOK {
+			t.Errorf("restored.Match(%q) = (%q, %v), want (%q, %v)", domain, gotRule, gotOK, wantRule, wantOK)
+		}
+	}
+}